@@ -1,8 +1,12 @@
 package ast
 
+import "bellamy/token"
+
 type Node interface {
 	TokenLiteral() string
 	String() string
+	// Pos reports where in the source the node begins
+	Pos() token.Position
 }
 
 type Statement interface {
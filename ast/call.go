@@ -7,9 +7,21 @@ import (
 )
 
 type CallExpression struct {
-	Token token.Token
-	Function Expression
+	Token     token.Token
+	Function  Expression
 	Arguments []Expression
+	// NamedArguments follow the positional Arguments, like `b: 3` in `f(1, b: 3)`
+	NamedArguments []*NamedArgument
+}
+
+// NamedArgument is an argument passed by the name of the parameter it is for
+type NamedArgument struct {
+	Name  *Identifier
+	Value Expression
+}
+
+func (na *NamedArgument) String() string {
+	return na.Name.String() + ": " + na.Value.String()
 }
 
 func (ce *CallExpression) expressionNode() {}
@@ -18,6 +30,10 @@ func (ce *CallExpression) TokenLiteral() string {
 	return ce.Token.Literal
 }
 
+func (ce *CallExpression) Pos() token.Position {
+	return ce.Token.Pos
+}
+
 func (ce *CallExpression) String() string {
 	var out bytes.Buffer
 
@@ -25,6 +41,9 @@ func (ce *CallExpression) String() string {
 	for _, a := range ce.Arguments {
 		args = append(args, a.String())
 	}
+	for _, a := range ce.NamedArguments {
+		args = append(args, a.String())
+	}
 
 	out.WriteString(ce.Function.String())
 	out.WriteString("(")
@@ -13,6 +13,10 @@ func (e *ExpressionStatement) TokenLiteral() string {
 	return e.Token.Literal
 }
 
+func (e *ExpressionStatement) Pos() token.Position {
+	return e.Token.Pos
+}
+
 func (e *ExpressionStatement) String() string {
 	if e.Expression != nil {
 		return e.Expression.String()
@@ -7,9 +7,12 @@ import (
 )
 
 type FunctionLiteral struct {
-	Token       token.Token
-	Parameters []*Identifier
-	Body        *BlockStatement
+	Token      token.Token
+	Parameters []*Parameter
+	Body       *BlockStatement
+	// Name is the name the function is bound to by `let name = fn...`,
+	// it is empty for functions that are not directly bound to a name
+	Name string
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
@@ -18,6 +21,10 @@ func (fl *FunctionLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
 
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
 func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 	params := []string{}
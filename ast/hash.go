@@ -8,7 +8,12 @@ import (
 
 type HashLiteral struct {
 	Token token.Token // the { token
-	Pairs map[Expression]Expression
+	Pairs []HashPair  // in source order
+}
+
+type HashPair struct {
+	Key   Expression
+	Value Expression
 }
 
 func (hl *HashLiteral) expressionNode() {}
@@ -17,11 +22,15 @@ func (hl *HashLiteral) TokenLiteral() string {
 	return hl.Token.Literal
 }
 
+func (hl *HashLiteral) Pos() token.Position {
+	return hl.Token.Pos
+}
+
 func (hl *HashLiteral) String() string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for k, v := range hl.Pairs {
-		pairs = append(pairs, k.String()+":"+v.String())
+	for _, pair := range hl.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 
 	out.WriteString("{")
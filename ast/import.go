@@ -0,0 +1,30 @@
+package ast
+
+import (
+	"bellamy/token"
+	"bytes"
+)
+
+type ImportExpression struct {
+	Token token.Token // the import token
+	Path  Expression
+}
+
+func (ie *ImportExpression) expressionNode() {}
+
+func (ie *ImportExpression) TokenLiteral() string {
+	return ie.Token.Literal
+}
+
+func (ie *ImportExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
+func (ie *ImportExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString(ie.TokenLiteral())
+	out.WriteString("(")
+	out.WriteString(ie.Path.String())
+	out.WriteString(")")
+	return out.String()
+}
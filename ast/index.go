@@ -5,10 +5,12 @@ import (
 	"bytes"
 )
 
+// IndexExpression is `a[i]`, or `a?[i]` when Optional, which evaluates to null when a is null
 type IndexExpression struct {
-	Token token.Token
-	Left  Expression
-	Index Expression
+	Token    token.Token // the [ or ?[ token
+	Left     Expression
+	Index    Expression
+	Optional bool
 }
 
 func (ie *IndexExpression) expressionNode() {}
@@ -17,11 +19,15 @@ func (ie *IndexExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+func (ie *IndexExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
 	out.WriteString(ie.Left.String())
-	out.WriteString("[")
+	out.WriteString(ie.Token.Literal)
 	out.WriteString(ie.Index.String())
 	out.WriteString("])")
 	return out.String()
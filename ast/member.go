@@ -0,0 +1,34 @@
+package ast
+
+import (
+	"bellamy/token"
+	"bytes"
+)
+
+// MemberExpression is a property access such as `arr.len`, which when called
+// like `arr.len()` becomes the Function of a CallExpression.
+// Optional is set for `h?.key`, which evaluates to null when h is null.
+type MemberExpression struct {
+	Token    token.Token // the . or ?. token
+	Object   Expression
+	Property *Identifier
+	Optional bool
+}
+
+func (me *MemberExpression) expressionNode() {}
+
+func (me *MemberExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+
+func (me *MemberExpression) Pos() token.Position {
+	return me.Token.Pos
+}
+
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString(me.Object.String())
+	out.WriteString(me.Token.Literal)
+	out.WriteString(me.Property.String())
+	return out.String()
+}
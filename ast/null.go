@@ -0,0 +1,23 @@
+package ast
+
+import (
+	"bellamy/token"
+)
+
+type NullLiteral struct {
+	Token token.Token
+}
+
+func (n *NullLiteral) expressionNode() {}
+
+func (n *NullLiteral) TokenLiteral() string {
+	return n.Token.Literal
+}
+
+func (n *NullLiteral) Pos() token.Position {
+	return n.Token.Pos
+}
+
+func (n *NullLiteral) String() string {
+	return n.TokenLiteral()
+}
@@ -0,0 +1,29 @@
+package ast
+
+import (
+	"bellamy/token"
+)
+
+// Parameter is one parameter of a FunctionLiteral. It may have a Default, used when
+// no argument is given for it, or be a Rest parameter, `...name`, which collects the
+// remaining positional arguments into an array.
+type Parameter struct {
+	Name    *Identifier
+	Default Expression
+	Rest    bool
+}
+
+func (p *Parameter) Pos() token.Position {
+	return p.Name.Pos()
+}
+
+func (p *Parameter) String() string {
+	switch {
+	case p.Rest:
+		return "..." + p.Name.String()
+	case p.Default != nil:
+		return p.Name.String() + " = " + p.Default.String()
+	default:
+		return p.Name.String()
+	}
+}
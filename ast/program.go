@@ -1,6 +1,9 @@
 package ast
 
-import "bytes"
+import (
+	"bellamy/token"
+	"bytes"
+)
 
 type Program struct {
 	Statements []Statement
@@ -13,6 +16,13 @@ func (p *Program) TokenLiteral() string {
 	return ""
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+	return token.Position{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 	for _, s := range p.Statements {
@@ -0,0 +1,43 @@
+package ast
+
+import (
+	"bellamy/token"
+	"bytes"
+)
+
+// SliceExpression takes part of a string or array, `s[start:end]`.
+// Start and End are nil when left out of the source, and Optional is set for
+// `s?[start:end]`, which evaluates to null when s is null.
+type SliceExpression struct {
+	Token    token.Token // the [ or ?[ token
+	Left     Expression
+	Start    Expression
+	End      Expression
+	Optional bool
+}
+
+func (se *SliceExpression) expressionNode() {}
+
+func (se *SliceExpression) TokenLiteral() string {
+	return se.Token.Literal
+}
+
+func (se *SliceExpression) Pos() token.Position {
+	return se.Token.Pos
+}
+
+func (se *SliceExpression) String() string {
+	var out bytes.Buffer
+	out.WriteString("(")
+	out.WriteString(se.Left.String())
+	out.WriteString(se.Token.Literal)
+	if se.Start != nil {
+		out.WriteString(se.Start.String())
+	}
+	out.WriteString(":")
+	if se.End != nil {
+		out.WriteString(se.End.String())
+	}
+	out.WriteString("])")
+	return out.String()
+}
@@ -0,0 +1,37 @@
+package ast
+
+import (
+	"bellamy/token"
+	"bytes"
+)
+
+// TemplateLiteral is an interpolated string such as "total: ${count * 2}". Its parts
+// are StringLiterals for the literal text and the embedded expressions in between.
+type TemplateLiteral struct {
+	Token token.Token
+	Parts []Expression
+}
+
+func (tl *TemplateLiteral) expressionNode() {}
+
+func (tl *TemplateLiteral) TokenLiteral() string {
+	return tl.Token.Literal
+}
+
+func (tl *TemplateLiteral) Pos() token.Position {
+	return tl.Token.Pos
+}
+
+func (tl *TemplateLiteral) String() string {
+	var out bytes.Buffer
+	out.WriteString(`"`)
+	for _, part := range tl.Parts {
+		if s, ok := part.(*StringLiteral); ok {
+			out.WriteString(s.Value)
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	out.WriteString(`"`)
+	return out.String()
+}
@@ -0,0 +1,85 @@
+package methods
+
+import (
+	"bellamy/builtins/static"
+	"bellamy/object"
+)
+
+// Methods holds the builtins that can be called on a value with method syntax, keyed
+// by the type of that value. The receiver is passed to the builtin as its first
+// argument, so `[1, 2].push(3)` is the same as `push([1, 2], 3)`.
+var Methods = map[object.ObjectType]map[string]*object.Builtin{
+	object.ARRAY_OBJ: {
+		"len":   static.StaticBuiltins["len"],
+		"first": static.StaticBuiltins["first"],
+		"last":  static.StaticBuiltins["last"],
+		"tail":  static.StaticBuiltins["tail"],
+		"push":  static.StaticBuiltins["push"],
+
+		"map":     static.StaticBuiltins["map"],
+		"filter":  static.StaticBuiltins["filter"],
+		"reduce":  static.StaticBuiltins["reduce"],
+		"sort":    static.StaticBuiltins["sort"],
+		"find":    static.StaticBuiltins["find"],
+		"any":     static.StaticBuiltins["any"],
+		"all":     static.StaticBuiltins["all"],
+		"zip":     static.StaticBuiltins["zip"],
+		"flatten": static.StaticBuiltins["flatten"],
+		"join":    static.StaticBuiltins["join"],
+	},
+	object.STRING_OBJ: {
+		"len":   static.StaticBuiltins["len"],
+		"upper": static.StaticBuiltins["upper"],
+		"lower": static.StaticBuiltins["lower"],
+
+		"split":      static.StaticBuiltins["split"],
+		"trim":       static.StaticBuiltins["trim"],
+		"trimLeft":   static.StaticBuiltins["trimLeft"],
+		"trimRight":  static.StaticBuiltins["trimRight"],
+		"contains":   static.StaticBuiltins["contains"],
+		"startsWith": static.StaticBuiltins["startsWith"],
+		"endsWith":   static.StaticBuiltins["endsWith"],
+		"indexOf":    static.StaticBuiltins["indexOf"],
+		"replace":    static.StaticBuiltins["replace"],
+		"repeat":     static.StaticBuiltins["repeat"],
+		"substring":  static.StaticBuiltins["substring"],
+		"chars":      static.StaticBuiltins["chars"],
+		"bytes":      static.StaticBuiltins["bytes"],
+		"runes":      static.StaticBuiltins["runes"],
+	},
+	object.HASH_OBJ: {
+		"len":     static.StaticBuiltins["len"],
+		"keys":    static.StaticBuiltins["keys"],
+		"values":  static.StaticBuiltins["values"],
+		"entries": static.StaticBuiltins["entries"],
+		"has":     static.StaticBuiltins["has"],
+		"delete":  static.StaticBuiltins["delete"],
+		"merge":   static.StaticBuiltins["merge"],
+	},
+	object.TUPLE_OBJ: {
+		"len": static.StaticBuiltins["len"],
+	},
+	object.INTEGER_OBJ: {
+		"abs":   static.StaticBuiltins["abs"],
+		"float": static.StaticBuiltins["float"],
+	},
+	object.FLOAT_OBJ: {
+		"abs":   static.StaticBuiltins["abs"],
+		"int":   static.StaticBuiltins["int"],
+		"round": static.StaticBuiltins["round"],
+		"floor": static.StaticBuiltins["floor"],
+		"ceil":  static.StaticBuiltins["ceil"],
+	},
+}
+
+// Lookup finds the method called name on receiver and binds receiver to it
+func Lookup(receiver object.Object, name string) (*object.Builtin, bool) {
+	method, ok := Methods[receiver.Type()][name]
+	if !ok {
+		return nil, false
+	}
+	bound := func(apply object.Applier, args ...object.Object) object.Object {
+		return method.Fn(apply, append([]object.Object{receiver}, args...)...)
+	}
+	return &object.Builtin{Fn: bound}, true
+}
@@ -1,8 +1,12 @@
 package static
 
-import "bellamy/object"
+import (
+	"bellamy/object"
+	"math"
+	"sort"
+)
 
-func last(args ...object.Object) object.Object {
+func last(_ object.Applier, args ...object.Object) object.Object {
 	if len(args) != 1 {
 		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
 	}
@@ -17,7 +21,7 @@ func last(args ...object.Object) object.Object {
 	return object.NULL
 }
 
-func first(args ...object.Object) object.Object {
+func first(_ object.Applier, args ...object.Object) object.Object {
 	if len(args) != 1 {
 		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
 	}
@@ -31,7 +35,7 @@ func first(args ...object.Object) object.Object {
 	return object.NULL
 }
 
-func push(args ...object.Object) object.Object {
+func push(_ object.Applier, args ...object.Object) object.Object {
 	if len(args) != 2 {
 		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 2)
 	}
@@ -46,7 +50,7 @@ func push(args ...object.Object) object.Object {
 	return &object.Array{Elements: newEl}
 }
 
-func tail(args ...object.Object) object.Object {
+func tail(_ object.Applier, args ...object.Object) object.Object {
 	if len(args) != 1 {
 		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
 	}
@@ -62,3 +66,265 @@ func tail(args ...object.Object) object.Object {
 	}
 	return object.NULL
 }
+
+func mapArray(apply object.Applier, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 2)
+	}
+	if args[0].Type() != object.ARRAY_OBJ {
+		return object.NewError("argument to `map` must be ARRAY, got %s", args[0].Type())
+	}
+	arr := args[0].(*object.Array)
+	newEls := make([]object.Object, len(arr.Elements))
+	for i, el := range arr.Elements {
+		result := apply(args[1], el)
+		if isError(result) {
+			return result
+		}
+		newEls[i] = result
+	}
+	return &object.Array{Elements: newEls}
+}
+
+func filter(apply object.Applier, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 2)
+	}
+	if args[0].Type() != object.ARRAY_OBJ {
+		return object.NewError("argument to `filter` must be ARRAY, got %s", args[0].Type())
+	}
+	arr := args[0].(*object.Array)
+	newEls := []object.Object{}
+	for _, el := range arr.Elements {
+		result := apply(args[1], el)
+		if isError(result) {
+			return result
+		}
+		if object.IsTruthy(result) {
+			newEls = append(newEls, el)
+		}
+	}
+	return &object.Array{Elements: newEls}
+}
+
+// reduce folds the array into a single value with fn(accumulator, element).
+// Without an initial value the first element is used to start with.
+func reduce(apply object.Applier, args ...object.Object) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return object.NewError("wrong number of arguments. got %d, expected %d or %d", len(args), 2, 3)
+	}
+	if args[0].Type() != object.ARRAY_OBJ {
+		return object.NewError("argument to `reduce` must be ARRAY, got %s", args[0].Type())
+	}
+	elements := args[0].(*object.Array).Elements
+	var acc object.Object
+	if len(args) == 3 {
+		acc = args[2]
+	} else if len(elements) > 0 {
+		acc = elements[0]
+		elements = elements[1:]
+	} else {
+		return object.NewError("`reduce` of empty ARRAY with no initial value")
+	}
+	for _, el := range elements {
+		acc = apply(args[1], acc, el)
+		if isError(acc) {
+			return acc
+		}
+	}
+	return acc
+}
+
+// sortArray returns a sorted copy of the array. The optional comparator is
+// called as fn(a, b) and must return true when a belongs before b.
+func sortArray(apply object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return object.NewError("wrong number of arguments. got %d, expected %d or %d", len(args), 1, 2)
+	}
+	if args[0].Type() != object.ARRAY_OBJ {
+		return object.NewError("argument to `sort` must be ARRAY, got %s", args[0].Type())
+	}
+	arr := args[0].(*object.Array)
+	newEls := make([]object.Object, len(arr.Elements))
+	copy(newEls, arr.Elements)
+
+	var err object.Object
+	less := func(a, b object.Object) bool {
+		less, lessErr := compare(a, b)
+		if lessErr != nil {
+			err = lessErr
+		}
+		return less
+	}
+	if len(args) == 2 {
+		less = func(a, b object.Object) bool {
+			result := apply(args[1], a, b)
+			if isError(result) {
+				err = result
+				return false
+			}
+			if result.Type() != object.BOOLEAN_OBJ {
+				err = object.NewError("`sort` comparator must return BOOLEAN, got %s", result.Type())
+				return false
+			}
+			return result == object.TRUE
+		}
+	}
+
+	sort.SliceStable(newEls, func(i, j int) bool {
+		if err != nil {
+			return false
+		}
+		return less(newEls[i], newEls[j])
+	})
+	if err != nil {
+		return err
+	}
+	return &object.Array{Elements: newEls}
+}
+
+// compare orders two elements for sort when no comparator was given
+func compare(a, b object.Object) (bool, *object.Error) {
+	switch {
+	case a.Type() == object.INTEGER_OBJ && b.Type() == object.INTEGER_OBJ:
+		return a.(*object.Integer).Value < b.(*object.Integer).Value, nil
+	case object.IsNumber(a) && object.IsNumber(b):
+		return object.NumberValue(a) < object.NumberValue(b), nil
+	case a.Type() == object.STRING_OBJ && b.Type() == object.STRING_OBJ:
+		return a.(*object.String).Value < b.(*object.String).Value, nil
+	default:
+		return false, object.NewError("`sort` cannot compare %s and %s without a comparator", a.Type(), b.Type())
+	}
+}
+
+func find(apply object.Applier, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 2)
+	}
+	if args[0].Type() != object.ARRAY_OBJ {
+		return object.NewError("argument to `find` must be ARRAY, got %s", args[0].Type())
+	}
+	for _, el := range args[0].(*object.Array).Elements {
+		result := apply(args[1], el)
+		if isError(result) {
+			return result
+		}
+		if object.IsTruthy(result) {
+			return el
+		}
+	}
+	return object.NULL
+}
+
+func anyOf(apply object.Applier, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 2)
+	}
+	if args[0].Type() != object.ARRAY_OBJ {
+		return object.NewError("argument to `any` must be ARRAY, got %s", args[0].Type())
+	}
+	for _, el := range args[0].(*object.Array).Elements {
+		result := apply(args[1], el)
+		if isError(result) {
+			return result
+		}
+		if object.IsTruthy(result) {
+			return object.TRUE
+		}
+	}
+	return object.FALSE
+}
+
+func allOf(apply object.Applier, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 2)
+	}
+	if args[0].Type() != object.ARRAY_OBJ {
+		return object.NewError("argument to `all` must be ARRAY, got %s", args[0].Type())
+	}
+	for _, el := range args[0].(*object.Array).Elements {
+		result := apply(args[1], el)
+		if isError(result) {
+			return result
+		}
+		if !object.IsTruthy(result) {
+			return object.FALSE
+		}
+	}
+	return object.TRUE
+}
+
+// zip pairs up the elements of each array, stopping at the end of the shortest one
+func zip(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) < 2 {
+		return object.NewError("wrong number of arguments. got %d, expected at least %d", len(args), 2)
+	}
+	length := -1
+	for _, arg := range args {
+		if arg.Type() != object.ARRAY_OBJ {
+			return object.NewError("argument to `zip` must be ARRAY, got %s", arg.Type())
+		}
+		if l := len(arg.(*object.Array).Elements); length == -1 || l < length {
+			length = l
+		}
+	}
+	newEls := make([]object.Object, length)
+	for i := range newEls {
+		tuple := make([]object.Object, len(args))
+		for j, arg := range args {
+			tuple[j] = arg.(*object.Array).Elements[i]
+		}
+		newEls[i] = &object.Array{Elements: tuple}
+	}
+	return &object.Array{Elements: newEls}
+}
+
+// rangeOf builds an array of integers, called as range(end), range(start, end) or range(start, end, step)
+func rangeOf(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) < 1 || len(args) > 3 {
+		return object.NewError("wrong number of arguments. got %d, expected %d to %d", len(args), 1, 3)
+	}
+	bounds := []int64{0, 0, 1}
+	for i, arg := range args {
+		if arg.Type() != object.INTEGER_OBJ {
+			return object.NewError("argument to `range` must be INTEGER, got %s", arg.Type())
+		}
+		bounds[i] = arg.(*object.Integer).Value
+	}
+	start, end, step := bounds[0], bounds[1], bounds[2]
+	if len(args) == 1 {
+		start, end = 0, bounds[0]
+	}
+	if step == 0 {
+		return object.NewError("`range` step must not be 0")
+	}
+
+	newEls := []object.Object{}
+	for i := start; (step > 0 && i < end) || (step < 0 && i > end); i += step {
+		newEls = append(newEls, &object.Integer{Value: i})
+		// Stop before the next step would wrap around past the limits of INTEGER
+		if (step > 0 && i > math.MaxInt64-step) || (step < 0 && i < math.MinInt64-step) {
+			break
+		}
+	}
+	return &object.Array{Elements: newEls}
+}
+
+// flatten removes one level of nesting from an array
+func flatten(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	if args[0].Type() != object.ARRAY_OBJ {
+		return object.NewError("argument to `flatten` must be ARRAY, got %s", args[0].Type())
+	}
+	newEls := []object.Object{}
+	for _, el := range args[0].(*object.Array).Elements {
+		if inner, ok := el.(*object.Array); ok {
+			newEls = append(newEls, inner.Elements...)
+		} else {
+			newEls = append(newEls, el)
+		}
+	}
+	return &object.Array{Elements: newEls}
+}
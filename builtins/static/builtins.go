@@ -3,6 +3,7 @@ package static
 import (
 	"bellamy/object"
 	"fmt"
+	"unicode/utf8"
 )
 
 var StaticBuiltins = map[string]*object.Builtin{
@@ -12,23 +13,75 @@ var StaticBuiltins = map[string]*object.Builtin{
 	"last":  &object.Builtin{Fn: last},
 	"tail":  &object.Builtin{Fn: tail},
 	"push":  &object.Builtin{Fn: push},
+	"tuple": &object.Builtin{Fn: tuple},
+
+	"upper":      &object.Builtin{Fn: upper},
+	"lower":      &object.Builtin{Fn: lower},
+	"split":      &object.Builtin{Fn: split},
+	"join":       &object.Builtin{Fn: join},
+	"trim":       &object.Builtin{Fn: trim},
+	"trimLeft":   &object.Builtin{Fn: trimLeft},
+	"trimRight":  &object.Builtin{Fn: trimRight},
+	"contains":   &object.Builtin{Fn: contains},
+	"startsWith": &object.Builtin{Fn: startsWith},
+	"endsWith":   &object.Builtin{Fn: endsWith},
+	"indexOf":    &object.Builtin{Fn: indexOf},
+	"replace":    &object.Builtin{Fn: replace},
+	"repeat":     &object.Builtin{Fn: repeat},
+	"substring":  &object.Builtin{Fn: substring},
+	"chars":      &object.Builtin{Fn: chars},
+	"bytes":      &object.Builtin{Fn: bytesOf},
+	"runes":      &object.Builtin{Fn: runesOf},
+
+	"abs":   &object.Builtin{Fn: abs},
+	"int":   &object.Builtin{Fn: toInt},
+	"float": &object.Builtin{Fn: toFloat},
+	"round": &object.Builtin{Fn: round},
+	"floor": &object.Builtin{Fn: floor},
+	"ceil":  &object.Builtin{Fn: ceil},
+
+	"keys":    &object.Builtin{Fn: keys},
+	"values":  &object.Builtin{Fn: values},
+	"entries": &object.Builtin{Fn: entries},
+	"has":     &object.Builtin{Fn: has},
+	"delete":  &object.Builtin{Fn: deleteKeys},
+	"merge":   &object.Builtin{Fn: merge},
+
+	"map":     &object.Builtin{Fn: mapArray},
+	"filter":  &object.Builtin{Fn: filter},
+	"reduce":  &object.Builtin{Fn: reduce},
+	"sort":    &object.Builtin{Fn: sortArray},
+	"find":    &object.Builtin{Fn: find},
+	"any":     &object.Builtin{Fn: anyOf},
+	"all":     &object.Builtin{Fn: allOf},
+	"zip":     &object.Builtin{Fn: zip},
+	"range":   &object.Builtin{Fn: rangeOf},
+	"flatten": &object.Builtin{Fn: flatten},
 }
 
-func length(args ...object.Object) object.Object {
+func isError(o object.Object) bool {
+	return o != nil && o.Type() == object.ERROR_OBJ
+}
+
+func length(_ object.Applier, args ...object.Object) object.Object {
 	if len(args) != 1 {
 		return object.NewError("wrong number of arguments. got %d, expected 1", len(args))
 	}
 	switch arg := args[0].(type) {
 	case *object.String:
-		return &object.Integer{Value: int64(len(arg.Value))}
+		return &object.Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
 	case *object.Array:
 		return &object.Integer{Value: int64(len(arg.Elements))}
+	case *object.Tuple:
+		return &object.Integer{Value: int64(len(arg.Elements))}
+	case *object.Hash:
+		return &object.Integer{Value: int64(arg.Len())}
 	default:
 		return object.NewError("argument to `len` not supported, got %s", args[0].Type())
 	}
 }
 
-func print(args ...object.Object) object.Object {
+func print(_ object.Applier, args ...object.Object) object.Object {
 	for _, arg := range args {
 		fmt.Println(arg.Inspect())
 	}
@@ -0,0 +1,66 @@
+package static
+
+import (
+	"bellamy/object"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// toFloat converts integers and numeric strings to floats
+func toFloat(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		return &object.Float{Value: float64(arg.Value)}
+	case *object.Float:
+		return arg
+	case *object.String:
+		v, err := strconv.ParseFloat(strings.TrimSpace(arg.Value), 64)
+		if err != nil {
+			return object.NewError("could not convert %q to FLOAT", arg.Value)
+		}
+		return &object.Float{Value: v}
+	default:
+		return object.NewError("argument to `float` not supported, got %s", args[0].Type())
+	}
+}
+
+func round(_ object.Applier, args ...object.Object) object.Object {
+	return roundWith("round", math.Round, args)
+}
+
+func floor(_ object.Applier, args ...object.Object) object.Object {
+	return roundWith("floor", math.Floor, args)
+}
+
+func ceil(_ object.Applier, args ...object.Object) object.Object {
+	return roundWith("ceil", math.Ceil, args)
+}
+
+// roundWith applies one of the math rounding functions to a number, returning an INTEGER
+func roundWith(name string, fn func(float64) float64, args []object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		return arg
+	case *object.Float:
+		return floatToInt(fn(arg.Value))
+	default:
+		return object.NewError("argument to `%s` must be INTEGER or FLOAT, got %s", name, args[0].Type())
+	}
+}
+
+// floatToInt converts a whole float to an INTEGER, failing for NaN, the infinities and
+// anything else out of the range of an INTEGER instead of letting it wrap
+func floatToInt(f float64) object.Object {
+	// float64(math.MaxInt64) rounds up to 2^63, the first value past the range
+	if math.IsNaN(f) || f >= math.MaxInt64 || f < math.MinInt64 {
+		return object.NewError("%s is out of range for INTEGER", (&object.Float{Value: f}).Inspect())
+	}
+	return &object.Integer{Value: int64(f)}
+}
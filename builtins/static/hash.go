@@ -0,0 +1,127 @@
+package static
+
+import "bellamy/object"
+
+// hashArg returns arg as a hash, or an error naming the builtin if it is not a HASH
+func hashArg(name string, arg object.Object) (*object.Hash, *object.Error) {
+	hash, ok := arg.(*object.Hash)
+	if !ok {
+		return nil, object.NewError("argument to `%s` must be HASH, got %s", name, arg.Type())
+	}
+	return hash, nil
+}
+
+// keyArg returns arg in the form used to look it up in a hash
+func keyArg(name string, arg object.Object) (object.Hashable, *object.Error) {
+	key, ok := object.ToHashable(arg)
+	if !ok {
+		return nil, object.NewError("`%s` key is unusable as hash key: %s", name, arg.Type())
+	}
+	return key, nil
+}
+
+// keys returns the keys of a hash in insertion order
+func keys(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	hash, err := hashArg("keys", args[0])
+	if err != nil {
+		return err
+	}
+	elements := make([]object.Object, hash.Len())
+	for i, pair := range hash.Pairs() {
+		elements[i] = pair.Key
+	}
+	return &object.Array{Elements: elements}
+}
+
+// values returns the values of a hash in insertion order
+func values(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	hash, err := hashArg("values", args[0])
+	if err != nil {
+		return err
+	}
+	elements := make([]object.Object, hash.Len())
+	for i, pair := range hash.Pairs() {
+		elements[i] = pair.Value
+	}
+	return &object.Array{Elements: elements}
+}
+
+// entries returns the pairs of a hash in insertion order as [key, value] arrays
+func entries(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	hash, err := hashArg("entries", args[0])
+	if err != nil {
+		return err
+	}
+	elements := make([]object.Object, hash.Len())
+	for i, pair := range hash.Pairs() {
+		elements[i] = &object.Array{Elements: []object.Object{pair.Key, pair.Value}}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// has reports whether a hash holds key, even when the value stored there is null
+func has(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 2)
+	}
+	hash, err := hashArg("has", args[0])
+	if err != nil {
+		return err
+	}
+	key, err := keyArg("has", args[1])
+	if err != nil {
+		return err
+	}
+	if _, ok := hash.Get(key); ok {
+		return object.TRUE
+	}
+	return object.FALSE
+}
+
+// deleteKeys returns a copy of a hash without the given keys, leaving the original as it was
+func deleteKeys(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) < 2 {
+		return object.NewError("wrong number of arguments. got %d, expected at least %d", len(args), 2)
+	}
+	hash, err := hashArg("delete", args[0])
+	if err != nil {
+		return err
+	}
+	result := hash.Copy()
+	for _, arg := range args[1:] {
+		key, err := keyArg("delete", arg)
+		if err != nil {
+			return err
+		}
+		result.Delete(key)
+	}
+	return result
+}
+
+// merge returns a new hash with the pairs of every hash given. When a key appears in
+// more than one, the value from the last wins but the key keeps its first position.
+func merge(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) < 1 {
+		return object.NewError("wrong number of arguments. got %d, expected at least %d", len(args), 1)
+	}
+	result := object.NewHash()
+	for _, arg := range args {
+		hash, err := hashArg("merge", arg)
+		if err != nil {
+			return err
+		}
+		for _, pair := range hash.Pairs() {
+			result.Set(pair.Key.(object.Hashable), pair.Value)
+		}
+	}
+	return result
+}
@@ -1 +1,46 @@
 package static
+
+import (
+	"bellamy/object"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func abs(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		if arg.Value < 0 {
+			return &object.Integer{Value: -arg.Value}
+		}
+		return arg
+	case *object.Float:
+		return &object.Float{Value: math.Abs(arg.Value)}
+	default:
+		return object.NewError("argument to `abs` must be INTEGER or FLOAT, got %s", args[0].Type())
+	}
+}
+
+// toInt converts floats, truncating towards zero, and numeric strings to integers
+func toInt(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	switch arg := args[0].(type) {
+	case *object.Integer:
+		return arg
+	case *object.Float:
+		return floatToInt(math.Trunc(arg.Value))
+	case *object.String:
+		v, err := strconv.ParseInt(strings.TrimSpace(arg.Value), 10, 64)
+		if err != nil {
+			return object.NewError("could not convert %q to INTEGER", arg.Value)
+		}
+		return &object.Integer{Value: v}
+	default:
+		return object.NewError("argument to `int` not supported, got %s", args[0].Type())
+	}
+}
@@ -1 +1,279 @@
 package static
+
+import (
+	"bellamy/object"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func upper(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	if args[0].Type() != object.STRING_OBJ {
+		return object.NewError("argument to `upper` must be STRING, got %s", args[0].Type())
+	}
+	return &object.String{Value: strings.ToUpper(args[0].(*object.String).Value)}
+}
+
+func lower(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	if args[0].Type() != object.STRING_OBJ {
+		return object.NewError("argument to `lower` must be STRING, got %s", args[0].Type())
+	}
+	return &object.String{Value: strings.ToLower(args[0].(*object.String).Value)}
+}
+
+// stringArg returns the value of arg, or an error naming the builtin if it is not a STRING
+func stringArg(name string, arg object.Object) (string, *object.Error) {
+	str, ok := arg.(*object.String)
+	if !ok {
+		return "", object.NewError("argument to `%s` must be STRING, got %s", name, arg.Type())
+	}
+	return str.Value, nil
+}
+
+// intArg returns the value of arg, or an error naming the builtin if it is not an INTEGER
+func intArg(name string, arg object.Object) (int64, *object.Error) {
+	i, ok := arg.(*object.Integer)
+	if !ok {
+		return 0, object.NewError("argument to `%s` must be INTEGER, got %s", name, arg.Type())
+	}
+	return i.Value, nil
+}
+
+func stringArray(values []string) *object.Array {
+	elements := make([]object.Object, len(values))
+	for i, v := range values {
+		elements[i] = &object.String{Value: v}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// split breaks a string on sep, or on runs of whitespace when no sep is given
+func split(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return object.NewError("wrong number of arguments. got %d, expected 1 or 2", len(args))
+	}
+	s, err := stringArg("split", args[0])
+	if err != nil {
+		return err
+	}
+	if len(args) == 1 {
+		return stringArray(strings.Fields(s))
+	}
+	sep, err := stringArg("split", args[1])
+	if err != nil {
+		return err
+	}
+	return stringArray(strings.Split(s, sep))
+}
+
+func join(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 && len(args) != 2 {
+		return object.NewError("wrong number of arguments. got %d, expected 1 or 2", len(args))
+	}
+	arr, ok := args[0].(*object.Array)
+	if !ok {
+		return object.NewError("argument to `join` must be ARRAY, got %s", args[0].Type())
+	}
+	sep := ""
+	if len(args) == 2 {
+		var err *object.Error
+		if sep, err = stringArg("join", args[1]); err != nil {
+			return err
+		}
+	}
+	values := make([]string, len(arr.Elements))
+	for i, el := range arr.Elements {
+		value, err := stringArg("join", el)
+		if err != nil {
+			return object.NewError("`join` requires an ARRAY of STRING, got %s at index %d", el.Type(), i)
+		}
+		values[i] = value
+	}
+	return &object.String{Value: strings.Join(values, sep)}
+}
+
+// trimWith builds a trim builtin that strips whitespace, or the characters
+// in an optional cutset argument
+func trimWith(name string, trimSpace func(string) string, trimCutset func(string, string) string) object.BuiltinFunction {
+	return func(_ object.Applier, args ...object.Object) object.Object {
+		if len(args) != 1 && len(args) != 2 {
+			return object.NewError("wrong number of arguments. got %d, expected 1 or 2", len(args))
+		}
+		s, err := stringArg(name, args[0])
+		if err != nil {
+			return err
+		}
+		if len(args) == 1 {
+			return &object.String{Value: trimSpace(s)}
+		}
+		cutset, err := stringArg(name, args[1])
+		if err != nil {
+			return err
+		}
+		return &object.String{Value: trimCutset(s, cutset)}
+	}
+}
+
+var (
+	trim      = trimWith("trim", strings.TrimSpace, strings.Trim)
+	trimLeft  = trimWith("trimLeft", func(s string) string { return strings.TrimLeftFunc(s, unicode.IsSpace) }, strings.TrimLeft)
+	trimRight = trimWith("trimRight", func(s string) string { return strings.TrimRightFunc(s, unicode.IsSpace) }, strings.TrimRight)
+)
+
+// stringPredicate builds a builtin taking two strings and returning a boolean
+func stringPredicate(name string, fn func(string, string) bool) object.BuiltinFunction {
+	return func(_ object.Applier, args ...object.Object) object.Object {
+		if len(args) != 2 {
+			return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 2)
+		}
+		s, err := stringArg(name, args[0])
+		if err != nil {
+			return err
+		}
+		sub, err := stringArg(name, args[1])
+		if err != nil {
+			return err
+		}
+		if fn(s, sub) {
+			return object.TRUE
+		}
+		return object.FALSE
+	}
+}
+
+var (
+	contains   = stringPredicate("contains", strings.Contains)
+	startsWith = stringPredicate("startsWith", strings.HasPrefix)
+	endsWith   = stringPredicate("endsWith", strings.HasSuffix)
+)
+
+// indexOf returns the code point index of the first occurrence of sub in s, or -1 if there is none
+func indexOf(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 2)
+	}
+	s, err := stringArg("indexOf", args[0])
+	if err != nil {
+		return err
+	}
+	sub, err := stringArg("indexOf", args[1])
+	if err != nil {
+		return err
+	}
+	i := strings.Index(s, sub)
+	if i == -1 {
+		return &object.Integer{Value: -1}
+	}
+	return &object.Integer{Value: int64(utf8.RuneCountInString(s[:i]))}
+}
+
+// replace returns s with every occurrence of old replaced by new
+func replace(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 3 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 3)
+	}
+	values := make([]string, 3)
+	for i, arg := range args {
+		value, err := stringArg("replace", arg)
+		if err != nil {
+			return err
+		}
+		values[i] = value
+	}
+	return &object.String{Value: strings.ReplaceAll(values[0], values[1], values[2])}
+}
+
+func repeat(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 2 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 2)
+	}
+	s, err := stringArg("repeat", args[0])
+	if err != nil {
+		return err
+	}
+	count, err := intArg("repeat", args[1])
+	if err != nil {
+		return err
+	}
+	if count < 0 {
+		return object.NewError("negative count for `repeat`: %d", count)
+	}
+	return &object.String{Value: strings.Repeat(s, int(count))}
+}
+
+// substring returns the code points of s from start up to, but not including, end.
+// end defaults to the length of s.
+func substring(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 2 && len(args) != 3 {
+		return object.NewError("wrong number of arguments. got %d, expected 2 or 3", len(args))
+	}
+	s, err := stringArg("substring", args[0])
+	if err != nil {
+		return err
+	}
+	start, err := intArg("substring", args[1])
+	if err != nil {
+		return err
+	}
+	runes := []rune(s)
+	end := int64(len(runes))
+	if len(args) == 3 {
+		if end, err = intArg("substring", args[2]); err != nil {
+			return err
+		}
+	}
+	if start < 0 || end > int64(len(runes)) || start > end {
+		return object.NewError("substring bounds out of range [%d:%d] with length %d", start, end, len(runes))
+	}
+	return &object.String{Value: string(runes[start:end])}
+}
+
+// chars splits a string into an array of strings holding one code point each
+func chars(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	s, err := stringArg("chars", args[0])
+	if err != nil {
+		return err
+	}
+	return stringArray(strings.Split(s, ""))
+}
+
+// bytesOf returns the UTF-8 encoding of a string as an array of integers
+func bytesOf(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	s, err := stringArg("bytes", args[0])
+	if err != nil {
+		return err
+	}
+	elements := make([]object.Object, len(s))
+	for i := 0; i < len(s); i++ {
+		elements[i] = &object.Integer{Value: int64(s[i])}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// runesOf returns the code points of a string as an array of integers
+func runesOf(_ object.Applier, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return object.NewError("wrong number of arguments. got %d, expected %d", len(args), 1)
+	}
+	s, err := stringArg("runes", args[0])
+	if err != nil {
+		return err
+	}
+	elements := []object.Object{}
+	for _, r := range s {
+		elements = append(elements, &object.Integer{Value: int64(r)})
+	}
+	return &object.Array{Elements: elements}
+}
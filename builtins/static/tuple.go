@@ -0,0 +1,16 @@
+package static
+
+import "bellamy/object"
+
+// tuple builds a tuple from its arguments. Any arrays among them are frozen into tuples too.
+func tuple(_ object.Applier, args ...object.Object) object.Object {
+	elements := make([]object.Object, len(args))
+	for i, arg := range args {
+		key, ok := object.ToHashable(arg)
+		if !ok {
+			return object.NewError("argument to `tuple` must be usable as a hash key, got %s", arg.Type())
+		}
+		elements[i] = key
+	}
+	return &object.Tuple{Elements: elements}
+}
@@ -0,0 +1,739 @@
+package compiler
+
+import (
+	"bellamy/ast"
+	"bellamy/builtins/static"
+	"bellamy/object"
+	"bellamy/token"
+	"fmt"
+)
+
+// Bytecode is a compiled program, ready to be run by the vm
+type Bytecode struct {
+	Main      *Function
+	Constants []object.Object
+	// Globals lays out the variables defined at the top level
+	Globals *Scope
+}
+
+type Compiler struct {
+	constants []object.Object
+	// names and builtins hold the constant index of each name and builtin already in the constant pool
+	names    map[string]int
+	builtins map[string]int
+	symbols  *SymbolTable
+	// scopes holds the functions being compiled, innermost last
+	scopes []*compilation
+}
+
+// compilation is the state of a single function while it is compiled
+type compilation struct {
+	fn *Function
+	// pos is the position of the node being compiled, given to the instructions emitted for it
+	pos token.Position
+	// blocks is the number of block scopes entered at the current point of the function
+	blocks int
+	loops  []*loop
+	// inFunction is false for the top level of a program
+	inFunction bool
+}
+
+// loop tracks a loop being compiled, so that break and continue know where to jump
+type loop struct {
+	continueTarget int
+	breaks         []int
+	// blocks is the number of block scopes entered outside the loop
+	blocks int
+}
+
+var infixOperators = map[string]Opcode{
+	"+":  OpAdd,
+	"-":  OpSub,
+	"*":  OpMul,
+	"/":  OpDiv,
+	"%":  OpMod,
+	"==": OpEqual,
+	"!=": OpNotEqual,
+	"<":  OpLess,
+	"<=": OpLessEqual,
+	">":  OpGreater,
+	">=": OpGreaterEqual,
+}
+
+var operatorsByOpcode = map[Opcode]string{}
+
+func init() {
+	for operator, op := range infixOperators {
+		operatorsByOpcode[op] = operator
+	}
+}
+
+// InfixOperator gives the operator an infix Opcode applies
+func InfixOperator(op Opcode) string {
+	return operatorsByOpcode[op]
+}
+
+func New() *Compiler {
+	return NewWithState(NewSymbolTable(), []object.Object{})
+}
+
+// NewWithState creates a compiler that carries on from the globals and constants
+// of earlier compilations, as the REPL does from one line to the next
+func NewWithState(symbols *SymbolTable, constants []object.Object) *Compiler {
+	c := &Compiler{constants: constants, names: map[string]int{}, builtins: map[string]int{}, symbols: symbols}
+	c.scopes = []*compilation{{fn: &Function{Scope: symbols.Scope}}}
+	return c
+}
+
+// Compile compiles program as the main function of the bytecode
+func (c *Compiler) Compile(program *ast.Program) error {
+	// Top level variables are defined up front, so that functions can refer to
+	// those defined after them
+	for _, stmt := range program.Statements {
+		if let, ok := stmt.(*ast.LetStatement); ok {
+			c.symbols.Define(let.Name.Value)
+		}
+	}
+
+	if len(program.Statements) == 0 {
+		c.emit(OpNull)
+	}
+	for i, stmt := range program.Statements {
+		if err := c.compile(stmt, false); err != nil {
+			return err
+		}
+		if i < len(program.Statements)-1 {
+			c.emit(OpPop)
+		}
+	}
+	c.emit(OpReturnValue)
+	return nil
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	return &Bytecode{
+		Main:      c.current().fn,
+		Constants: c.constants,
+		Globals:   c.symbols.Scope,
+	}
+}
+
+// compile emits the code for node, which leaves exactly one value on the stack.
+// tail is set when node is in tail position, where a call can replace the function making it.
+func (c *Compiler) compile(node ast.Node, tail bool) error {
+	defer c.at(node.Pos())()
+
+	switch node := node.(type) {
+	case *ast.ExpressionStatement:
+		// The parser leaves the expression out of an empty statement, as in "};"
+		if node.Expression == nil {
+			c.emit(OpNull)
+			return nil
+		}
+		return c.compile(node.Expression, tail)
+	case *ast.LetStatement:
+		// The variable is defined before its value is compiled so that functions can call themselves
+		symbol := c.symbols.Define(node.Name.Value)
+		if err := c.compile(node.Value, false); err != nil {
+			return err
+		}
+		c.setSymbol(symbol)
+	case *ast.ReturnStatement:
+		if err := c.compile(node.ReturnValue, c.current().inFunction); err != nil {
+			return err
+		}
+		c.emit(OpReturnValue)
+	case *ast.BlockStatement:
+		return c.compileBlock(node, tail)
+	case *ast.WhileStatement:
+		return c.compileWhile(node)
+	case *ast.ForStatement:
+		return c.compileFor(node)
+	case *ast.BreakStatement:
+		return c.compileLoopJump(node, true)
+	case *ast.ContinueStatement:
+		return c.compileLoopJump(node, false)
+
+	case *ast.IntegerLiteral:
+		c.emit(OpConstant, c.addConstant(&object.Integer{Value: node.Value}))
+	case *ast.FloatLiteral:
+		c.emit(OpConstant, c.addConstant(&object.Float{Value: node.Value}))
+	case *ast.StringLiteral:
+		c.emit(OpConstant, c.addConstant(&object.String{Value: node.Value}))
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(OpTrue)
+		} else {
+			c.emit(OpFalse)
+		}
+	case *ast.NullLiteral:
+		c.emit(OpNull)
+	case *ast.TemplateLiteral:
+		for _, part := range node.Parts {
+			if err := c.compile(part, false); err != nil {
+				return err
+			}
+		}
+		c.emit(OpTemplate, len(node.Parts))
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			if err := c.compile(el, false); err != nil {
+				return err
+			}
+		}
+		c.emit(OpArray, len(node.Elements))
+	case *ast.HashLiteral:
+		for _, pair := range node.Pairs {
+			if err := c.compile(pair.Key, false); err != nil {
+				return err
+			}
+			c.emitAt(pair.Key.Pos(), OpHashKey)
+			if err := c.compile(pair.Value, false); err != nil {
+				return err
+			}
+		}
+		c.emit(OpHash, len(node.Pairs))
+	case *ast.FunctionLiteral:
+		return c.compileFunction(node)
+
+	case *ast.Identifier:
+		c.getVariable(node.Value)
+	case *ast.AssignExpression:
+		return c.compileAssign(node)
+	case *ast.PrefixExpression:
+		if err := c.compile(node.Right, false); err != nil {
+			return err
+		}
+		switch node.Operator {
+		case "!":
+			c.emit(OpBang)
+		case "-":
+			c.emit(OpMinus)
+		default:
+			return c.errorf("unknown operator: %s", node.Operator)
+		}
+	case *ast.InfixExpression:
+		return c.compileInfix(node)
+	case *ast.IfExpression:
+		return c.compileIf(node, tail)
+	case *ast.CallExpression, *ast.IndexExpression, *ast.SliceExpression, *ast.MemberExpression:
+		return c.compileChain(node.(ast.Expression), tail)
+	case *ast.ImportExpression:
+		if err := c.compile(node.Path, false); err != nil {
+			return err
+		}
+		c.emit(OpImport)
+	default:
+		return c.errorf("cannot compile %T", node)
+	}
+	return nil
+}
+
+// compileBlock leaves the value of the last statement of block, or null when it has none
+func (c *Compiler) compileBlock(block *ast.BlockStatement, tail bool) error {
+	if len(block.Statements) == 0 {
+		c.emit(OpNull)
+	}
+	for i, stmt := range block.Statements {
+		last := i == len(block.Statements)-1
+		if err := c.compile(stmt, tail && last); err != nil {
+			return err
+		}
+		if !last {
+			c.emit(OpPop)
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) compileInfix(node *ast.InfixExpression) error {
+	if err := c.compile(node.Left, false); err != nil {
+		return err
+	}
+
+	// The logical operators only evaluate their right side when the left does not decide the result
+	var jump int
+	switch node.Operator {
+	case "&&":
+		jump = c.emit(OpJumpNotTruthy, 0)
+	case "||":
+		jump = c.emit(OpJumpTruthy, 0)
+	case "??":
+		jump = c.emit(OpJumpNotNull, 0)
+	default:
+		if err := c.compile(node.Right, false); err != nil {
+			return err
+		}
+		op, ok := infixOperators[node.Operator]
+		if !ok {
+			return c.errorf("unknown operator: %s", node.Operator)
+		}
+		c.emit(op)
+		return nil
+	}
+
+	if err := c.compile(node.Right, false); err != nil {
+		return err
+	}
+	if node.Operator == "??" {
+		c.patchJump(jump)
+		return nil
+	}
+	c.emit(OpTruthy)
+	end := c.emit(OpJump, 0)
+	c.patchJump(jump)
+	if node.Operator == "&&" {
+		c.emit(OpFalse)
+	} else {
+		c.emit(OpTrue)
+	}
+	c.patchJump(end)
+	return nil
+}
+
+func (c *Compiler) compileIf(node *ast.IfExpression, tail bool) error {
+	if err := c.compile(node.Condition, false); err != nil {
+		return err
+	}
+	jumpToElse := c.emit(OpJumpNotTruthy, 0)
+	if err := c.compileBlock(node.Consequence, tail); err != nil {
+		return err
+	}
+	jumpToEnd := c.emit(OpJump, 0)
+
+	c.patchJump(jumpToElse)
+	if node.Alternative == nil {
+		c.emit(OpNull)
+	} else if err := c.compileBlock(node.Alternative, tail); err != nil {
+		return err
+	}
+	c.patchJump(jumpToEnd)
+	return nil
+}
+
+func (c *Compiler) compileWhile(node *ast.WhileStatement) error {
+	start := c.offset()
+	if err := c.compile(node.Condition, false); err != nil {
+		return err
+	}
+	exit := c.emit(OpJumpNotTruthy, 0)
+
+	l := c.enterLoop(start)
+	c.enterBlock()
+	if err := c.compileBlock(node.Body, false); err != nil {
+		return err
+	}
+	c.emit(OpPop)
+	c.leaveBlock()
+	c.emit(OpJump, start)
+	c.leaveLoop()
+
+	c.patchJump(exit)
+	for _, jump := range l.breaks {
+		c.patchJump(jump)
+	}
+	c.emit(OpNull)
+	return nil
+}
+
+func (c *Compiler) compileFor(node *ast.ForStatement) error {
+	if err := c.compile(node.Iterable, false); err != nil {
+		return err
+	}
+	c.emitAt(node.Iterable.Pos(), OpIter)
+
+	start := c.offset()
+	exit := c.emit(OpIterNext, 0)
+	l := c.enterLoop(start)
+
+	// Each iteration gets its own scope so closures capture that iteration's value
+	c.enterBlock()
+	variable := c.symbols.Define(node.Variable.Value)
+	c.setSymbol(variable)
+	c.emit(OpPop)
+	if err := c.compileBlock(node.Body, false); err != nil {
+		return err
+	}
+	c.emit(OpPop)
+	c.leaveBlock()
+	c.emit(OpJump, start)
+	c.leaveLoop()
+
+	c.patchJump(exit)
+	for _, jump := range l.breaks {
+		c.patchJump(jump)
+	}
+	// Drop the iterator
+	c.emit(OpPop)
+	c.emit(OpNull)
+	return nil
+}
+
+// compileLoopJump compiles a break, or a continue, leaving the block scopes entered inside the loop
+func (c *Compiler) compileLoopJump(node ast.Statement, isBreak bool) error {
+	scope := c.current()
+	if len(scope.loops) == 0 {
+		return c.errorf("%s outside of a loop", node.TokenLiteral())
+	}
+	l := scope.loops[len(scope.loops)-1]
+	for i := l.blocks; i < scope.blocks; i++ {
+		c.emit(OpPopScope)
+	}
+	if isBreak {
+		l.breaks = append(l.breaks, c.emit(OpJump, 0))
+	} else {
+		c.emit(OpJump, l.continueTarget)
+	}
+	return nil
+}
+
+func (c *Compiler) compileFunction(node *ast.FunctionLiteral) error {
+	c.enterFunction()
+
+	simple := true
+	for _, param := range node.Parameters {
+		c.symbols.Define(param.Name.Value)
+		simple = simple && param.Default == nil && !param.Rest
+	}
+	// Parameters not given an argument are left without a value, for their default to fill in
+	for i, param := range node.Parameters {
+		if param.Default == nil {
+			continue
+		}
+		jump := c.emit(OpJumpIfSet, i, 0)
+		if err := c.compile(param.Default, false); err != nil {
+			return err
+		}
+		c.emit(OpSetLocal, i)
+		c.emit(OpPop)
+		c.patchOperand(jump, 1)
+	}
+
+	if err := c.compileBlock(node.Body, true); err != nil {
+		return err
+	}
+	c.emit(OpReturnValue)
+
+	fn := c.leaveFunction()
+	fn.Literal = node
+	fn.Simple = simple
+	c.emit(OpClosure, c.addConstant(fn))
+	return nil
+}
+
+func (c *Compiler) compileAssign(node *ast.AssignExpression) error {
+	// Compound operators such as += combine the value with the target's current value
+	compound := node.Operator != "="
+	compileValue := func() error {
+		if err := c.compile(node.Value, false); err != nil {
+			return err
+		}
+		if compound {
+			op, ok := infixOperators[node.Operator[:len(node.Operator)-1]]
+			if !ok {
+				return c.errorf("unknown operator: %s", node.Operator)
+			}
+			c.emit(op)
+		}
+		return nil
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		if compound {
+			c.getVariable(target.Value)
+		}
+		if err := compileValue(); err != nil {
+			return err
+		}
+		defer c.at(target.Pos())()
+		c.assignVariable(target.Value)
+		return nil
+	case *ast.IndexExpression:
+		if err := c.compile(target.Left, false); err != nil {
+			return err
+		}
+		if err := c.compile(target.Index, false); err != nil {
+			return err
+		}
+	case *ast.MemberExpression:
+		if err := c.compile(target.Object, false); err != nil {
+			return err
+		}
+		c.emit(OpMemberTarget)
+		c.emit(OpConstant, c.nameConstant(target.Property.Value))
+	default:
+		return c.errorf("cannot assign to %s", node.Target)
+	}
+
+	if compound {
+		c.emit(OpDupTwo)
+		c.emit(OpIndex)
+	}
+	if err := compileValue(); err != nil {
+		return err
+	}
+	c.emit(OpSetIndex)
+	return nil
+}
+
+// compileChain compiles a chain of member, index, slice and call expressions such as
+// `config?.servers[0].host()`. Once an optional ?. or ?[ link finds null the rest of
+// the chain is skipped, leaving null as the value of the whole chain.
+func (c *Compiler) compileChain(node ast.Expression, tail bool) error {
+	var skips []int
+	if err := c.compileLink(node, &skips, tail); err != nil {
+		return err
+	}
+	for _, jump := range skips {
+		c.patchJump(jump)
+	}
+	return nil
+}
+
+func (c *Compiler) compileLink(node ast.Expression, skips *[]int, tail bool) error {
+	defer c.at(node.Pos())()
+
+	switch node := node.(type) {
+	case *ast.MemberExpression:
+		if err := c.compileLinkBase(node.Object, node.Optional, skips); err != nil {
+			return err
+		}
+		c.emit(OpMember, c.nameConstant(node.Property.Value))
+	case *ast.IndexExpression:
+		if err := c.compileLinkBase(node.Left, node.Optional, skips); err != nil {
+			return err
+		}
+		if err := c.compile(node.Index, false); err != nil {
+			return err
+		}
+		c.emit(OpIndex)
+	case *ast.SliceExpression:
+		if err := c.compileLinkBase(node.Left, node.Optional, skips); err != nil {
+			return err
+		}
+		bounds := 0
+		if node.Start != nil {
+			if err := c.compile(node.Start, false); err != nil {
+				return err
+			}
+			bounds |= SliceStart
+		}
+		if node.End != nil {
+			if err := c.compile(node.End, false); err != nil {
+				return err
+			}
+			bounds |= SliceEnd
+		}
+		c.emit(OpSlice, bounds)
+	case *ast.CallExpression:
+		return c.compileCall(node, skips, tail)
+	default:
+		return c.compile(node, false)
+	}
+	return nil
+}
+
+// compileLinkBase compiles the left side of a link, skipping the rest of the chain when
+// the link is optional and the left side is null
+func (c *Compiler) compileLinkBase(base ast.Expression, optional bool, skips *[]int) error {
+	if err := c.compileLink(base, skips, false); err != nil {
+		return err
+	}
+	if optional {
+		*skips = append(*skips, c.emit(OpJumpNull, 0))
+	}
+	return nil
+}
+
+func (c *Compiler) compileCall(node *ast.CallExpression, skips *[]int, tail bool) error {
+	if err := c.compileLinkBase(node.Function, false, skips); err != nil {
+		return err
+	}
+	for _, arg := range node.Arguments {
+		if err := c.compile(arg, false); err != nil {
+			return err
+		}
+	}
+	if len(node.Arguments) > 255 {
+		return c.errorf("too many arguments in call, got %d", len(node.Arguments))
+	}
+
+	if len(node.NamedArguments) == 0 {
+		if tail {
+			c.emit(OpTailCall, len(node.Arguments))
+		} else {
+			c.emit(OpCall, len(node.Arguments))
+		}
+		return nil
+	}
+
+	names := make([]object.Object, len(node.NamedArguments))
+	for i, arg := range node.NamedArguments {
+		if err := c.compile(arg.Value, false); err != nil {
+			return err
+		}
+		names[i] = &object.String{Value: arg.Name.Value}
+	}
+	namesConstant := c.addConstant(&object.Tuple{Elements: names})
+	if tail {
+		c.emit(OpTailCallNamed, len(node.Arguments), namesConstant)
+	} else {
+		c.emit(OpCallNamed, len(node.Arguments), namesConstant)
+	}
+	return nil
+}
+
+func (c *Compiler) getVariable(name string) {
+	symbol, ok := c.symbols.Resolve(name)
+	switch {
+	case !ok:
+		if builtin, isBuiltin := static.StaticBuiltins[name]; isBuiltin {
+			index, cached := c.builtins[name]
+			if !cached {
+				index = c.addConstant(builtin)
+				c.builtins[name] = index
+			}
+			c.emit(OpConstant, index)
+		} else {
+			// The variable may still be defined by the time this code runs
+			c.emit(OpGetName, c.nameConstant(name))
+		}
+	case symbol.Scope == GlobalScope:
+		c.emit(OpGetGlobal, symbol.Index)
+	case symbol.Scope == LocalScope:
+		c.emit(OpGetLocal, symbol.Index)
+	default:
+		c.emit(OpGetFree, symbol.Depth, symbol.Index)
+	}
+}
+
+func (c *Compiler) setSymbol(symbol Symbol) {
+	if symbol.Scope == GlobalScope {
+		c.emit(OpSetGlobal, symbol.Index)
+	} else {
+		c.emit(OpSetLocal, symbol.Index)
+	}
+}
+
+func (c *Compiler) assignVariable(name string) {
+	symbol, ok := c.symbols.Resolve(name)
+	switch {
+	case !ok:
+		c.emit(OpAssignName, c.nameConstant(name))
+	case symbol.Scope == GlobalScope:
+		c.emit(OpAssignGlobal, symbol.Index)
+	case symbol.Scope == LocalScope:
+		c.emit(OpAssignLocal, symbol.Index)
+	default:
+		c.emit(OpAssignFree, symbol.Depth, symbol.Index)
+	}
+}
+
+func (c *Compiler) current() *compilation {
+	return c.scopes[len(c.scopes)-1]
+}
+
+func (c *Compiler) enterFunction() {
+	c.symbols = NewEnclosedSymbolTable(c.symbols)
+	c.scopes = append(c.scopes, &compilation{
+		fn:         &Function{Scope: c.symbols.Scope},
+		pos:        c.current().pos,
+		inFunction: true,
+	})
+}
+
+func (c *Compiler) leaveFunction() *Function {
+	fn := c.current().fn
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.symbols = c.symbols.Outer
+	return fn
+}
+
+// enterBlock enters a new scope inside the current function
+func (c *Compiler) enterBlock() {
+	c.symbols = NewEnclosedSymbolTable(c.symbols)
+	scope := c.current()
+	scope.fn.Blocks = append(scope.fn.Blocks, c.symbols.Scope)
+	scope.blocks++
+	c.emit(OpPushScope, len(scope.fn.Blocks)-1)
+}
+
+func (c *Compiler) leaveBlock() {
+	c.emit(OpPopScope)
+	c.current().blocks--
+	c.symbols = c.symbols.Outer
+}
+
+func (c *Compiler) enterLoop(continueTarget int) *loop {
+	scope := c.current()
+	l := &loop{continueTarget: continueTarget, blocks: scope.blocks}
+	scope.loops = append(scope.loops, l)
+	return l
+}
+
+func (c *Compiler) leaveLoop() {
+	scope := c.current()
+	scope.loops = scope.loops[:len(scope.loops)-1]
+}
+
+// at makes pos the position of the instructions emitted until the returned function restores the last one
+func (c *Compiler) at(pos token.Position) func() {
+	scope := c.current()
+	prev := scope.pos
+	scope.pos = pos
+	return func() { scope.pos = prev }
+}
+
+func (c *Compiler) addConstant(obj object.Object) int {
+	c.constants = append(c.constants, obj)
+	return len(c.constants) - 1
+}
+
+// nameConstant adds name to the constant pool once, however many times it is used
+func (c *Compiler) nameConstant(name string) int {
+	if index, ok := c.names[name]; ok {
+		return index
+	}
+	index := c.addConstant(&object.String{Value: name})
+	c.names[name] = index
+	return index
+}
+
+func (c *Compiler) offset() int {
+	return len(c.current().fn.Instructions)
+}
+
+// emit adds an instruction at the current position, returning its offset
+func (c *Compiler) emit(op Opcode, operands ...int) int {
+	return c.emitAt(c.current().pos, op, operands...)
+}
+
+func (c *Compiler) emitAt(pos token.Position, op Opcode, operands ...int) int {
+	fn := c.current().fn
+	offset := len(fn.Instructions)
+	if n := len(fn.Positions); n == 0 || fn.Positions[n-1].Pos != pos {
+		fn.Positions = append(fn.Positions, Position{Offset: offset, Pos: pos})
+	}
+	fn.Instructions = append(fn.Instructions, Make(op, operands...)...)
+	return offset
+}
+
+// patchJump points the jump at offset to the next instruction to be emitted
+func (c *Compiler) patchJump(offset int) {
+	c.patchOperand(offset, 0)
+}
+
+// patchOperand sets the operand of the instruction at offset given by index to the
+// offset of the next instruction to be emitted
+func (c *Compiler) patchOperand(offset, index int) {
+	ins := c.current().fn.Instructions
+	def := definitions[Opcode(ins[offset])]
+	operands, _ := ReadOperands(def, ins[offset+1:])
+	operands[index] = len(ins)
+	copy(ins[offset:], Make(Opcode(ins[offset]), operands...))
+}
+
+func (c *Compiler) errorf(format string, a ...interface{}) error {
+	return fmt.Errorf("%s: %s", c.current().pos, fmt.Sprintf(format, a...))
+}
@@ -0,0 +1,156 @@
+package compiler
+
+import (
+	"bellamy/lexer"
+	"bellamy/object"
+	"bellamy/parser"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		input     string
+		main      []string
+		constants []interface{}
+	}{
+		{
+			"1 + 2",
+			[]string{"OpConstant 0", "OpConstant 1", "OpAdd", "OpReturnValue"},
+			[]interface{}{1, 2},
+		},
+		{
+			"let a = 1; a",
+			[]string{"OpConstant 0", "OpSetGlobal 0", "OpPop", "OpGetGlobal 0", "OpReturnValue"},
+			[]interface{}{1},
+		},
+		{
+			"if (true) { 10 } else { 20 }",
+			[]string{"OpTrue", "OpJumpNotTruthy 16", "OpConstant 0", "OpJump 21", "OpConstant 1", "OpReturnValue"},
+			[]interface{}{10, 20},
+		},
+		{
+			"let f = fn(x) { fn() { x } }; f(1)()",
+			[]string{"OpClosure 1", "OpSetGlobal 0", "OpPop", "OpGetGlobal 0", "OpConstant 2", "OpCall 1", "OpCall 0", "OpReturnValue"},
+			[]interface{}{
+				[]string{"OpGetFree 1 0", "OpReturnValue"},
+				[]string{"OpClosure 0", "OpReturnValue"},
+				1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		bytecode, err := compile(tt.input)
+		assert.NoError(t, err)
+		testInstructions(t, tt.main, bytecode.Main.Instructions)
+
+		assert.Equal(t, len(tt.constants), len(bytecode.Constants), tt.input)
+		for i, expected := range tt.constants {
+			switch expected := expected.(type) {
+			case int:
+				assert.Equal(t, &object.Integer{Value: int64(expected)}, bytecode.Constants[i])
+			case []string:
+				fn, ok := bytecode.Constants[i].(*Function)
+				assert.True(t, ok)
+				testInstructions(t, expected, fn.Instructions)
+			}
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "1:1: break outside of a loop"},
+		{"let f = fn() { continue; }", "1:16: continue outside of a loop"},
+	}
+
+	for _, tt := range tests {
+		_, err := compile(tt.input)
+		assert.EqualError(t, err, tt.expected)
+	}
+}
+
+func TestPositions(t *testing.T) {
+	bytecode, err := compile("let a = 1;\na + true")
+	assert.NoError(t, err)
+	// The OpAdd of the second line
+	assert.Equal(t, "2:3", bytecode.Main.PosAt(17).String())
+}
+
+func TestResolve(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+	outer := NewEnclosedSymbolTable(global)
+	outer.Define("b")
+	inner := NewEnclosedSymbolTable(outer)
+	inner.Define("c")
+	inner.Define("b")
+
+	tests := []struct {
+		table    *SymbolTable
+		name     string
+		expected Symbol
+	}{
+		{global, "a", Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+		{outer, "a", Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+		{outer, "b", Symbol{Name: "b", Scope: LocalScope, Index: 0}},
+		{inner, "c", Symbol{Name: "c", Scope: LocalScope, Index: 0}},
+		{inner, "b", Symbol{Name: "b", Scope: LocalScope, Index: 1}},
+		{NewEnclosedSymbolTable(inner), "c", Symbol{Name: "c", Scope: FreeScope, Index: 0, Depth: 1}},
+		{NewEnclosedSymbolTable(outer), "b", Symbol{Name: "b", Scope: FreeScope, Index: 0, Depth: 1}},
+	}
+
+	for _, tt := range tests {
+		symbol, ok := tt.table.Resolve(tt.name)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expected, symbol)
+	}
+
+	_, ok := inner.Resolve("d")
+	assert.False(t, ok)
+}
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 0, 0, 255, 254}},
+		{OpConstant, []int{70000}, []byte{byte(OpConstant), 0, 1, 17, 112}},
+		{OpAdd, []int{}, []byte{byte(OpAdd)}},
+		{OpGetFree, []int{2, 258}, []byte{byte(OpGetFree), 0, 0, 0, 2, 0, 0, 1, 2}},
+		{OpCall, []int{3}, []byte{byte(OpCall), 3}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, Make(tt.op, tt.operands...))
+	}
+}
+
+func compile(input string) (*Bytecode, error) {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	c := New()
+	if err := c.Compile(program); err != nil {
+		return nil, err
+	}
+	return c.Bytecode(), nil
+}
+
+func testInstructions(t *testing.T, expected []string, actual Instructions) {
+	lines := strings.Split(strings.TrimSuffix(actual.String(), "\n"), "\n")
+	for i, line := range lines {
+		// Drop the offset
+		lines[i] = line[5:]
+	}
+	assert.Equal(t, expected, lines)
+}
@@ -0,0 +1,54 @@
+package compiler
+
+import (
+	"bellamy/ast"
+	"bellamy/object"
+	"bellamy/token"
+	"sort"
+)
+
+const COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+
+// Function is the compiled code of a function literal, or of a whole program.
+// It is kept in the constant pool and turned into a closure when the literal is evaluated.
+type Function struct {
+	Instructions Instructions
+	// Scope holds the parameters and other variables of the function, parameters first
+	Scope *Scope
+	// Blocks holds the scopes of the for loop bodies in the function
+	Blocks []*Scope
+	// Positions maps instructions back to the source they were compiled from
+	Positions []Position
+	// Literal is nil for the function of a program
+	Literal *ast.FunctionLiteral
+	// Simple is set when every parameter is a plain one, with no default and no rest
+	Simple bool
+}
+
+// Position is the source position of the instructions from Offset up to the next Position
+type Position struct {
+	Offset int
+	Pos    token.Position
+}
+
+func (f *Function) Type() object.ObjectType {
+	return COMPILED_FUNCTION_OBJ
+}
+
+func (f *Function) Inspect() string {
+	if f.Literal == nil {
+		return "compiled program"
+	}
+	return "compiled " + f.Literal.String()
+}
+
+// PosAt finds the source position of the instruction at offset
+func (f *Function) PosAt(offset int) token.Position {
+	i := sort.Search(len(f.Positions), func(i int) bool {
+		return f.Positions[i].Offset > offset
+	})
+	if i == 0 {
+		return token.Position{}
+	}
+	return f.Positions[i-1].Pos
+}
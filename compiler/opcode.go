@@ -0,0 +1,263 @@
+package compiler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Instructions is a sequence of encoded instructions, each an Opcode followed by
+// its operands in big endian order
+type Instructions []byte
+
+type Opcode byte
+
+const (
+	// OpConstant pushes the constant at the index given by its operand
+	OpConstant Opcode = iota
+	OpPop
+	// OpDupTwo pushes another copy of the top two values on the stack
+	OpDupTwo
+	OpNull
+	OpTrue
+	OpFalse
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+	OpEqual
+	OpNotEqual
+	OpLess
+	OpLessEqual
+	OpGreater
+	OpGreaterEqual
+	OpMinus
+	OpBang
+	// OpTruthy replaces the top of the stack with its truthiness as a BOOLEAN
+	OpTruthy
+
+	// OpJumpNotTruthy and OpJumpTruthy pop the value they test. OpJumpNull and
+	// OpJumpNotNull leave it on the stack when they jump, and when they do not
+	// OpJumpNull leaves it there too while OpJumpNotNull drops the null.
+	OpJump
+	OpJumpNotTruthy
+	OpJumpTruthy
+	OpJumpNull
+	OpJumpNotNull
+
+	// The set and assign instructions leave the value they store on the stack. Set
+	// defines a variable while assign updates one that must be defined already.
+	OpGetGlobal
+	OpSetGlobal
+	OpAssignGlobal
+	OpGetLocal
+	OpSetLocal
+	OpAssignLocal
+	// OpGetFree and OpAssignFree take the number of scopes to go out through and the
+	// index of the variable in the scope they get to
+	OpGetFree
+	OpAssignFree
+	// OpGetName and OpAssignName look up a variable by name at run time, for names
+	// that were not defined yet when the code using them was compiled
+	OpGetName
+	OpAssignName
+	// OpJumpIfSet jumps when the local it is given has a value, and is used to skip
+	// the default of a parameter that was passed an argument
+	OpJumpIfSet
+
+	OpArray
+	OpHash
+	// OpHashKey converts the top of the stack into a value that can be a hash key
+	OpHashKey
+	// OpTemplate joins the given number of values into a string
+	OpTemplate
+	OpIndex
+	// OpSlice takes a bit set saying which of the bounds of the slice were given
+	OpSlice
+	OpMember
+	// OpMemberTarget checks that the top of the stack can have a member assigned to
+	OpMemberTarget
+	OpSetIndex
+
+	// OpCall takes the number of positional arguments. OpCallNamed also takes the
+	// constant holding the names of the named arguments, which follow the positional
+	// ones on the stack. The tail call versions replace the calling function.
+	OpCall
+	OpCallNamed
+	OpTailCall
+	OpTailCallNamed
+	OpReturnValue
+	OpClosure
+
+	// OpPushScope enters a new scope for the body of a for loop, given by its
+	// index in the Blocks of the function, and OpPopScope leaves it again
+	OpPushScope
+	OpPopScope
+	// OpIter replaces the top of the stack with an iterator over its items. OpIterNext
+	// pushes the next item of that iterator, or jumps once there are none left.
+	OpIter
+	OpIterNext
+
+	OpImport
+)
+
+// Definition names an Opcode and gives the width in bytes of each of its operands.
+// Constants, jump targets, variables and element counts take four bytes, so that no
+// program or session that fits in memory outgrows them. Single bytes only hold the
+// argument counts of calls, which the compiler checks, and the bounds of slices.
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{4}},
+	OpPop:      {"OpPop", []int{}},
+	OpDupTwo:   {"OpDupTwo", []int{}},
+	OpNull:     {"OpNull", []int{}},
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpMod:          {"OpMod", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpLess:         {"OpLess", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpGreater:      {"OpGreater", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpMinus:        {"OpMinus", []int{}},
+	OpBang:         {"OpBang", []int{}},
+	OpTruthy:       {"OpTruthy", []int{}},
+
+	OpJump:          {"OpJump", []int{4}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{4}},
+	OpJumpTruthy:    {"OpJumpTruthy", []int{4}},
+	OpJumpNull:      {"OpJumpNull", []int{4}},
+	OpJumpNotNull:   {"OpJumpNotNull", []int{4}},
+
+	OpGetGlobal:    {"OpGetGlobal", []int{4}},
+	OpSetGlobal:    {"OpSetGlobal", []int{4}},
+	OpAssignGlobal: {"OpAssignGlobal", []int{4}},
+	OpGetLocal:     {"OpGetLocal", []int{4}},
+	OpSetLocal:     {"OpSetLocal", []int{4}},
+	OpAssignLocal:  {"OpAssignLocal", []int{4}},
+	OpGetFree:      {"OpGetFree", []int{4, 4}},
+	OpAssignFree:   {"OpAssignFree", []int{4, 4}},
+	OpGetName:      {"OpGetName", []int{4}},
+	OpAssignName:   {"OpAssignName", []int{4}},
+	OpJumpIfSet:    {"OpJumpIfSet", []int{4, 4}},
+
+	OpArray:        {"OpArray", []int{4}},
+	OpHash:         {"OpHash", []int{4}},
+	OpHashKey:      {"OpHashKey", []int{}},
+	OpTemplate:     {"OpTemplate", []int{4}},
+	OpIndex:        {"OpIndex", []int{}},
+	OpSlice:        {"OpSlice", []int{1}},
+	OpMember:       {"OpMember", []int{4}},
+	OpMemberTarget: {"OpMemberTarget", []int{}},
+	OpSetIndex:     {"OpSetIndex", []int{}},
+
+	OpCall:          {"OpCall", []int{1}},
+	OpCallNamed:     {"OpCallNamed", []int{1, 4}},
+	OpTailCall:      {"OpTailCall", []int{1}},
+	OpTailCallNamed: {"OpTailCallNamed", []int{1, 4}},
+	OpReturnValue:   {"OpReturnValue", []int{}},
+	OpClosure:       {"OpClosure", []int{4}},
+
+	OpPushScope: {"OpPushScope", []int{4}},
+	OpPopScope:  {"OpPopScope", []int{}},
+	OpIter:      {"OpIter", []int{}},
+	OpIterNext:  {"OpIterNext", []int{4}},
+
+	OpImport: {"OpImport", []int{}},
+}
+
+// Slice bounds given to OpSlice
+const (
+	SliceStart = 1 << iota
+	SliceEnd
+)
+
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+	return def, nil
+}
+
+// Make encodes an instruction
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	length := 1
+	for _, w := range def.OperandWidths {
+		length += w
+	}
+
+	instruction := make([]byte, length)
+	instruction[0] = byte(op)
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 4:
+			binary.BigEndian.PutUint32(instruction[offset:], uint32(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+	return instruction
+}
+
+// ReadOperands decodes the operands of an instruction, returning them along with
+// the number of bytes they took up
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 4:
+			operands[i] = int(ReadUint32(ins[offset:]))
+		case 1:
+			operands[i] = int(ins[offset])
+		}
+		offset += width
+	}
+	return operands, offset
+}
+
+func ReadUint32(ins Instructions) uint32 {
+	return binary.BigEndian.Uint32(ins)
+}
+
+// String disassembles the instructions, one per line prefixed with its offset
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			return out.String()
+		}
+		operands, read := ReadOperands(def, ins[i+1:])
+		fmt.Fprintf(&out, "%04d %s", i, def.Name)
+		for _, o := range operands {
+			fmt.Fprintf(&out, " %d", o)
+		}
+		out.WriteString("\n")
+		i += 1 + read
+	}
+	return out.String()
+}
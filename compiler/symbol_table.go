@@ -0,0 +1,78 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	// GlobalScope holds the variables defined at the top level of a program
+	GlobalScope SymbolScope = "GLOBAL"
+	// LocalScope holds the variables of the scope being compiled
+	LocalScope SymbolScope = "LOCAL"
+	// FreeScope holds the variables of the scopes enclosing it, short of the global one
+	FreeScope SymbolScope = "FREE"
+)
+
+// Symbol is where a variable lives. Depth is the number of scopes out from the
+// current one that a FreeScope variable was defined in.
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+	Depth int
+}
+
+// Scope lays out the variables of a scope at run time, each at the index of its name
+type Scope struct {
+	Names []string
+}
+
+// SymbolTable tracks the variables defined in a scope while it is compiled. There is
+// one for the top level, one for each function and one for the body of each for loop.
+type SymbolTable struct {
+	Outer *SymbolTable
+	Scope *Scope
+	store map[string]int
+}
+
+func NewSymbolTable() *SymbolTable {
+	return &SymbolTable{Scope: &Scope{}, store: map[string]int{}}
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+	return s
+}
+
+// Define adds a variable to the scope, reusing its place when the scope already has one of that name
+func (s *SymbolTable) Define(name string) Symbol {
+	index, ok := s.store[name]
+	if !ok {
+		index = len(s.Scope.Names)
+		s.Scope.Names = append(s.Scope.Names, name)
+		s.store[name] = index
+	}
+	if s.Outer == nil {
+		return Symbol{Name: name, Scope: GlobalScope, Index: index}
+	}
+	return Symbol{Name: name, Scope: LocalScope, Index: index}
+}
+
+// Resolve finds the innermost variable called name that has been defined so far
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	depth := 0
+	for table := s; table != nil; table = table.Outer {
+		index, ok := table.store[name]
+		switch {
+		case !ok:
+			depth++
+			continue
+		case table.Outer == nil:
+			return Symbol{Name: name, Scope: GlobalScope, Index: index}, true
+		case depth == 0:
+			return Symbol{Name: name, Scope: LocalScope, Index: index}, true
+		default:
+			return Symbol{Name: name, Scope: FreeScope, Index: index, Depth: depth}, true
+		}
+	}
+	return Symbol{}, false
+}
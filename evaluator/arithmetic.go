@@ -0,0 +1,56 @@
+package evaluator
+
+import (
+	"bellamy/object"
+	"math"
+)
+
+// The integer operations below wrap around when they overflow an int64, unless
+// checked is set by Options.CheckedArithmetic
+func addInts(a, b int64, checked bool) object.Object {
+	sum := a + b
+	if checked && (a > 0 && b > 0 && sum < 0 || a < 0 && b < 0 && sum >= 0) {
+		return object.NewError("integer overflow: %d + %d", a, b)
+	}
+	return &object.Integer{Value: sum}
+}
+
+func subtractInts(a, b int64, checked bool) object.Object {
+	diff := a - b
+	if checked && (a >= 0 && b < 0 && diff < 0 || a < 0 && b > 0 && diff >= 0) {
+		return object.NewError("integer overflow: %d - %d", a, b)
+	}
+	return &object.Integer{Value: diff}
+}
+
+func multiplyInts(a, b int64, checked bool) object.Object {
+	product := a * b
+	if checked && a != 0 && (product/a != b || a == -1 && b == math.MinInt64 || b == -1 && a == math.MinInt64) {
+		return object.NewError("integer overflow: %d * %d", a, b)
+	}
+	return &object.Integer{Value: product}
+}
+
+func divideInts(a, b int64, checked bool) object.Object {
+	if b == 0 {
+		return object.NewError("division by zero: %d / %d", a, b)
+	}
+	if checked && a == math.MinInt64 && b == -1 {
+		return object.NewError("integer overflow: %d / %d", a, b)
+	}
+	return &object.Integer{Value: a / b}
+}
+
+func moduloInts(a, b int64) object.Object {
+	if b == 0 {
+		return object.NewError("modulo by zero: %d %% %d", a, b)
+	}
+	return &object.Integer{Value: a % b}
+}
+
+func negateInt(a int64, checked bool) object.Object {
+	if checked && a == math.MinInt64 {
+		return object.NewError("integer overflow: -(%d)", a)
+	}
+	return &object.Integer{Value: -a}
+}
@@ -0,0 +1,100 @@
+package evaluator
+
+import (
+	"bellamy/ast"
+	"bellamy/object"
+	"strings"
+)
+
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		if _, ok := env.Get(target.Value); !ok {
+			err := object.NewError("assignment to undeclared variable: %s", target.Value)
+			err.Pos = target.Pos()
+			return err
+		}
+		val := evalAssignedValue(node, env, func() object.Object {
+			return evalIdentifier(target, env)
+		})
+		if isError(val) {
+			return val
+		}
+		env.Assign(target.Value, val)
+		return val
+	case *ast.IndexExpression:
+		left := Eval(target.Left, env)
+		if isError(left) {
+			return left
+		}
+		index := Eval(target.Index, env)
+		if isError(index) {
+			return index
+		}
+		val := evalAssignedValue(node, env, func() object.Object {
+			return evalIndexExpression(left, index)
+		})
+		if isError(val) {
+			return val
+		}
+		return evalIndexAssignment(left, index, val)
+	case *ast.MemberExpression:
+		obj := Eval(target.Object, env)
+		if isError(obj) {
+			return obj
+		}
+		if obj.Type() != object.HASH_OBJ {
+			return object.NewError("cannot assign to member of %s", obj.Type())
+		}
+		key := &object.String{Value: target.Property.Value}
+		val := evalAssignedValue(node, env, func() object.Object {
+			return evalHashIndexExpression(obj, key)
+		})
+		if isError(val) {
+			return val
+		}
+		return evalIndexAssignment(obj, key, val)
+	default:
+		return object.NewError("cannot assign to %s", node.Target)
+	}
+}
+
+// evalAssignedValue evaluates the value to be stored by an assignment. Compound operators
+// such as += combine the value with the target's current value, read using current.
+func evalAssignedValue(node *ast.AssignExpression, env *object.Environment, current func() object.Object) object.Object {
+	if node.Operator == "=" {
+		return Eval(node.Value, env)
+	}
+	cur := current()
+	if isError(cur) {
+		return cur
+	}
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+	return evalInfixExpression(strings.TrimSuffix(node.Operator, "="), cur, val, interpreterOf(env).operators)
+}
+
+func evalIndexAssignment(left, index, val object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		arrayObject := left.(*object.Array)
+		i := index.(*object.Integer).Value
+		if i < 0 || i >= int64(len(arrayObject.Elements)) {
+			return object.NewError("index out of bounds of array, i=%d, a=%s", i, arrayObject.Inspect())
+		}
+		arrayObject.Elements[i] = val
+		return val
+	case left.Type() == object.HASH_OBJ:
+		hashObject := left.(*object.Hash)
+		key, err := HashKey(index)
+		if err != nil {
+			return err
+		}
+		hashObject.Set(key, val)
+		return val
+	default:
+		return object.NewError("index assignment not supported: %s", left.Type())
+	}
+}
@@ -0,0 +1,177 @@
+package evaluator
+
+import (
+	"bellamy/ast"
+	"bellamy/object"
+	"fmt"
+)
+
+// NamedArgument is an argument passed by the name of its parameter, `f(b: 3)`
+type NamedArgument struct {
+	Name  string
+	Value object.Object
+}
+
+// tailCall is a call in tail position, evaluated up to the point of making it. Rather
+// than being made where it is found, which would nest another Go stack frame for every
+// call, it is handed back to the callFunction that is running the enclosing function,
+// which makes it in place of that function once its body has finished.
+type tailCall struct {
+	node  *ast.CallExpression
+	fn    object.Object
+	args  []object.Object
+	named []NamedArgument
+}
+
+func (tc *tailCall) Type() object.ObjectType {
+	return "TAIL_CALL"
+}
+
+func (tc *tailCall) Inspect() string {
+	return "tail call to " + tc.node.String()
+}
+
+// callFunction calls fn, then keeps making any tail call it ends in until a real
+// value comes back, so tail recursion runs in constant Go stack space
+func callFunction(fn object.Object, args []object.Object, named []NamedArgument) object.Object {
+	return resolveTailCalls(invoke(fn, args, named))
+}
+
+// resolveTailCalls makes result, and every tail call that follows from it, when it is a tailCall
+func resolveTailCalls(result object.Object) object.Object {
+	for {
+		call, ok := result.(*tailCall)
+		if !ok {
+			return result
+		}
+		result = withPosition(invoke(call.fn, call.args, call.named), call.node)
+	}
+}
+
+// invoke runs a single call, which may end in a tail call that is left for the caller to make
+func invoke(fn object.Object, args []object.Object, named []NamedArgument) object.Object {
+	switch fn := fn.(type) {
+	case *object.Function:
+		extendedEnv, err := extendFunctionEnv(fn, args, named)
+		if err != nil {
+			return err
+		}
+		in := interpreterOf(fn.Env)
+		if in.depth == MaxCallDepth {
+			return object.NewError("stack overflow: calls nested more than %d deep", MaxCallDepth)
+		}
+		in.depth++
+		defer func() { in.depth-- }()
+		evaluated := evalTail(fn.Body, extendedEnv)
+		return unwrapReturnValue(evaluated)
+	case *object.Builtin:
+		if len(named) > 0 {
+			return object.NewError("builtin functions do not take named arguments, got %s", named[0].Name)
+		}
+		return fn.Fn(apply, args...)
+	default:
+		return object.NewError("not a function: %s", fn.Type())
+	}
+}
+
+// extendFunctionEnv binds the arguments of a call to the parameters of fn
+func extendFunctionEnv(fn *object.Function, args []object.Object, named []NamedArgument) (*object.Environment, *object.Error) {
+	values, err := BindArguments(fn.Parameters, fn.Describe(), args, named)
+	if err != nil {
+		return nil, err
+	}
+
+	env := object.NewEnclosedEnvironment(fn.Env)
+	for i, param := range fn.Parameters {
+		value := values[i]
+		if value == nil {
+			// Defaults are evaluated in the new environment, so they can use earlier parameters
+			value = Eval(param.Default, env)
+			if err, ok := value.(*object.Error); ok {
+				return nil, err
+			}
+		}
+		env.Set(param.Name.Value, value)
+	}
+	return env, nil
+}
+
+// BindArguments matches the arguments of a call to params, the parameters of the
+// function named by describe, and returns the value of each parameter in order. Each
+// parameter takes the positional argument in its place, or else the argument named
+// after it, or else its default, which is left as nil for the caller to evaluate.
+// A rest parameter takes all the positional arguments left over.
+func BindArguments(params []*ast.Parameter, describe string, args []object.Object, named []NamedArgument) ([]object.Object, *object.Error) {
+	byName := map[string]object.Object{}
+	for _, arg := range named {
+		if _, ok := byName[arg.Name]; ok {
+			return nil, object.NewError("argument %s given more than once to %s", arg.Name, describe)
+		}
+		byName[arg.Name] = arg.Value
+	}
+
+	hasRest := len(params) > 0 && params[len(params)-1].Rest
+	if !hasRest && len(args) > len(params) {
+		return nil, wrongArgumentCount(params, describe, len(args)+len(named))
+	}
+
+	values := make([]object.Object, len(params))
+	for i, param := range params {
+		name := param.Name.Value
+		value, isNamed := byName[name]
+		delete(byName, name)
+
+		switch {
+		case param.Rest:
+			if isNamed {
+				return nil, object.NewError("rest parameter %s of %s cannot be passed by name", name, describe)
+			}
+			rest := []object.Object{}
+			if i < len(args) {
+				rest = append(rest, args[i:]...)
+			}
+			value = &object.Array{Elements: rest}
+		case i < len(args):
+			if isNamed {
+				return nil, object.NewError("argument %s given more than once to %s", name, describe)
+			}
+			value = args[i]
+		case isNamed:
+		case param.Default != nil:
+		case len(named) == 0:
+			return nil, wrongArgumentCount(params, describe, len(args))
+		default:
+			return nil, object.NewError("missing argument %s to %s", name, describe)
+		}
+		values[i] = value
+	}
+
+	for _, arg := range named {
+		if _, ok := byName[arg.Name]; ok {
+			return nil, object.NewError("unknown argument %s to %s", arg.Name, describe)
+		}
+	}
+	return values, nil
+}
+
+func wrongArgumentCount(params []*ast.Parameter, describe string, got int) *object.Error {
+	required, optional, rest := 0, 0, false
+	for _, param := range params {
+		switch {
+		case param.Rest:
+			rest = true
+		case param.Default != nil:
+			optional++
+		default:
+			required++
+		}
+	}
+
+	expected := fmt.Sprint(required)
+	if rest {
+		expected = fmt.Sprintf("at least %d", required)
+	} else if optional > 0 {
+		expected = fmt.Sprintf("%d to %d", required, required+optional)
+	}
+	return object.NewError("wrong number of arguments to %s. got %d, expected %s", describe, got, expected)
+}
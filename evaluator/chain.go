@@ -0,0 +1,97 @@
+package evaluator
+
+import (
+	"bellamy/ast"
+	"bellamy/object"
+)
+
+// evalChain evaluates a chain of member, index, slice and call expressions such as
+// `config?.servers[0].host()`. Once an optional ?. or ?[ link finds null the rest of
+// the chain is skipped and the whole chain evaluates to null, which is reported
+// through skipped so that the enclosing links know to skip themselves as well.
+func evalChain(node ast.Expression, env *object.Environment) (result object.Object, skipped bool) {
+	switch node := node.(type) {
+	case *ast.MemberExpression:
+		result, skipped = evalMemberLink(node, env)
+	case *ast.IndexExpression:
+		result, skipped = evalIndexLink(node, env)
+	case *ast.SliceExpression:
+		result, skipped = evalSliceLink(node, env)
+	case *ast.CallExpression:
+		result, skipped = evalCallLink(node, env)
+	default:
+		return Eval(node, env), false
+	}
+	// Links are evaluated here rather than through Eval, so tag errors the same way
+	return withPosition(result, node), skipped
+}
+
+// evalChainBase evaluates the left side of a link, reporting whether the link should
+// be skipped because an earlier link was, or because it is optional and the left side is null
+func evalChainBase(base ast.Expression, optional bool, env *object.Environment) (object.Object, bool) {
+	obj, skipped := evalChain(base, env)
+	if skipped || (optional && obj == object.NULL) {
+		return object.NULL, true
+	}
+	return obj, false
+}
+
+func evalMemberLink(node *ast.MemberExpression, env *object.Environment) (object.Object, bool) {
+	obj, skipped := evalChainBase(node.Object, node.Optional, env)
+	if skipped || isError(obj) {
+		return obj, skipped
+	}
+	return evalMemberExpression(obj, node.Property.Value), false
+}
+
+func evalIndexLink(node *ast.IndexExpression, env *object.Environment) (object.Object, bool) {
+	left, skipped := evalChainBase(node.Left, node.Optional, env)
+	if skipped || isError(left) {
+		return left, skipped
+	}
+	index := Eval(node.Index, env)
+	if isError(index) {
+		return index, false
+	}
+	return evalIndexExpression(left, index), false
+}
+
+func evalSliceLink(node *ast.SliceExpression, env *object.Environment) (object.Object, bool) {
+	left, skipped := evalChainBase(node.Left, node.Optional, env)
+	if skipped || isError(left) {
+		return left, skipped
+	}
+	return evalSliceExpression(left, node, env), false
+}
+
+func evalCallLink(node *ast.CallExpression, env *object.Environment) (object.Object, bool) {
+	call, result, skipped := prepareCall(node, env)
+	if call == nil {
+		return result, skipped
+	}
+	// Make the magic happen!
+	return callFunction(call.fn, call.args, call.named), false
+}
+
+// prepareCall evaluates the function and arguments of a call without making it.
+// When the call is skipped by an optional chain or evaluating part of it fails,
+// call is nil and result holds the outcome instead.
+func prepareCall(node *ast.CallExpression, env *object.Environment) (call *tailCall, result object.Object, skipped bool) {
+	function, skipped := evalChainBase(node.Function, false, env)
+	if skipped || isError(function) {
+		return nil, function, skipped
+	}
+	args := evalExpressions(node.Arguments, env)
+	if len(args) == 1 && isError(args[0]) {
+		return nil, args[0], false
+	}
+	named := make([]NamedArgument, len(node.NamedArguments))
+	for i, arg := range node.NamedArguments {
+		value := Eval(arg.Value, env)
+		if isError(value) {
+			return nil, value, false
+		}
+		named[i] = NamedArgument{Name: arg.Name.Value, Value: value}
+	}
+	return &tailCall{node: node, fn: function, args: args, named: named}, nil, false
+}
@@ -0,0 +1,22 @@
+package evaluator_test
+
+import (
+	"bellamy/ast"
+	"bellamy/evaluator"
+	"bellamy/object"
+	"bellamy/vm"
+	"testing"
+)
+
+func runOnVM(program *ast.Program, options evaluator.Options) object.Object {
+	return vm.NewSession(options).Run(program)
+}
+
+func TestVMEngine(t *testing.T) {
+	restore := evaluator.UseEngine(runOnVM)
+	defer restore()
+
+	for _, tt := range evaluator.EngineTests {
+		t.Run(tt.Name, tt.Test)
+	}
+}
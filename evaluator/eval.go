@@ -2,49 +2,88 @@ package evaluator
 
 import (
 	"bellamy/ast"
+	"bellamy/builtins/methods"
 	"bellamy/builtins/static"
 	"bellamy/object"
+	"bytes"
+	"math"
 )
 
-// Eval has the main task of interpreting each node that it comes across in our parsed source code
+// Eval has the main task of interpreting each node that it comes across in our parsed source code.
+// Given a whole program, Eval turns any panic inside the interpreter into an error, so that
+// a bug triggered by a program can never take down the process running it.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	return withPosition(eval(node, env), node)
+}
+
+// withPosition tags an error result that has no position yet with the position of node.
+// Errors are created deep in the helpers below without knowledge of the source,
+// so the innermost node they pass through tags them with its position.
+func withPosition(result object.Object, node ast.Node) object.Object {
+	if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+	}
+	return result
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
 		return evalProgram(node, env)
 	case *ast.ExpressionStatement:
 		return Eval(node.Expression, env)
 	case *ast.ReturnStatement:
-		val := Eval(node.ReturnValue, env)
+		val := evalTail(node.ReturnValue, env)
 		if isError(val) {
 			return val
 		}
 		return &object.ReturnValue{Value: val}
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.TemplateLiteral:
+		return evalTemplateLiteral(node, env)
 	case *ast.Boolean:
 		return booleanObject(node.Value)
+	case *ast.NullLiteral:
+		return object.NULL
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalPrefixExpression(node.Operator, right)
+		return evalPrefixExpression(node.Operator, right, interpreterOf(env).operators)
 	case *ast.InfixExpression:
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
 		}
+		if node.Operator == "&&" || node.Operator == "||" {
+			return evalLogicalExpression(node.Operator, left, node.Right, env)
+		}
+		if node.Operator == "??" {
+			return evalNullishExpression(left, node.Right, env)
+		}
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right)
+		return evalInfixExpression(node.Operator, left, right, interpreterOf(env).operators)
 	case *ast.BlockStatement:
 		return evalBlockStatement(node, env)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+	case *ast.BreakStatement:
+		return object.BREAK
+	case *ast.ContinueStatement:
+		return object.CONTINUE
 	case *ast.LetStatement:
 		val := Eval(node.Value, env)
 		if isError(val) {
@@ -53,39 +92,28 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return env.Set(node.Name.Value, val)
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
 	case *ast.FunctionLiteral:
 		params := node.Parameters
 		body := node.Body
-		return &object.Function{Parameters: params, Env: env, Body: body}
+		return &object.Function{Parameters: params, Env: env, Body: body, Name: node.Name}
 	case *ast.CallExpression:
-		function := Eval(node.Function, env)
-		if isError(function) {
-			return function
-		}
-		args := evalExpressions(node.Arguments, env)
-		if len(args) == 1 && isError(args[0]) {
-			return args[0]
-		}
-		// Make the magic happen!
-		return applyFunction(function, args)
+		result, _ := evalChain(node, env)
+		return result
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0] // break out with the error
 		}
 		return &object.Array{Elements: elements}
-	case *ast.IndexExpression:
-		left := Eval(node.Left, env)
-		if isError(left) {
-			return left
-		}
-		index := Eval(node.Index, env)
-		if isError(index) {
-			return index
-		}
-		return evalIndexExpression(left, index)
+	case *ast.IndexExpression, *ast.SliceExpression, *ast.MemberExpression:
+		result, _ := evalChain(node.(ast.Expression), env)
+		return result
 	case *ast.HashLiteral:
 		return evalHashLiteral(node, env)
+	case *ast.ImportExpression:
+		return evalImportExpression(node, env)
 	default:
 		return object.NULL
 	}
@@ -98,13 +126,22 @@ func isError(o object.Object) bool {
 	return false
 }
 
-func evalProgram(program *ast.Program, env *object.Environment) object.Object {
-	var result object.Object
+func evalProgram(program *ast.Program, env *object.Environment) (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = object.NewError("internal error: %v", r)
+		}
+	}()
+
+	// The interpreter is set up before any environment is enclosed by env, so they all share it
+	interpreterOf(env)
+
 	for _, stmt := range program.Statements {
 		result = Eval(stmt, env)
 		switch result := result.(type) {
 		case *object.ReturnValue:
-			return result.Value
+			// A return at the top level may still hold a call to make
+			return resolveTailCalls(result.Value)
 		case *object.Error:
 			return result
 		}
@@ -117,8 +154,8 @@ func evalBlockStatement(block *ast.BlockStatement, env *object.Environment) obje
 	for _, stmt := range block.Statements {
 		result = Eval(stmt, env)
 		if result != nil {
-			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
+			switch result.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
 				return result
 			}
 		}
@@ -142,8 +179,14 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.TUPLE_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalTupleIndexExpression(left, index)
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
 	case left.Type() == object.HASH_OBJ:
 		return evalHashIndexExpression(left, index)
+	case left.Type() == object.MODULE_OBJ:
+		return evalModuleIndexExpression(left, index)
 	default:
 		return object.NewError("index operator not supported: %s", left.Type())
 	}
@@ -160,39 +203,65 @@ func evalArrayIndexExpression(left, index object.Object) object.Object {
 	return arrayObject.Elements[i]
 }
 
+func evalTupleIndexExpression(left, index object.Object) object.Object {
+	tupleObject := left.(*object.Tuple)
+	i := index.(*object.Integer).Value
+	if i < 0 || i >= int64(len(tupleObject.Elements)) {
+		return object.NewError("index out of bounds of tuple, i=%d, t=%s", i, tupleObject.Inspect())
+	}
+	return tupleObject.Elements[i]
+}
+
+func evalStringIndexExpression(left, index object.Object) object.Object {
+	// Strings are indexed by code point rather than by byte
+	runes := []rune(left.(*object.String).Value)
+	i := index.(*object.Integer).Value
+	if i < 0 || i >= int64(len(runes)) {
+		return object.NewError("index out of bounds of string, i=%d, len=%d", i, len(runes))
+	}
+	return &object.String{Value: string(runes[i])}
+}
+
 func evalHashIndexExpression(hash, index object.Object) object.Object {
 	hashObject := hash.(*object.Hash)
-	key, ok := index.(object.Hashable)
-	if !ok {
-		return object.NewError("unusable as hash key: %s", index.Type())
+	key, err := HashKey(index)
+	if err != nil {
+		return err
 	}
-	pair, ok := hashObject.Pairs[key.HashKey()]
+	value, ok := hashObject.Get(key)
 	if !ok {
 		return object.NULL
 	}
-	return pair.Value
+	return value
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
-	switch fn := fn.(type) {
-	case *object.Function:
-		extendedEnv := extendFunctionEnv(fn, args)
-		evaluated := Eval(fn.Body, extendedEnv)
-		return unwrapReturnValue(evaluated)
-	case *object.Builtin:
-		return fn.Fn(args...)
-	default:
-		return object.NewError("not a function: %s", fn.Type())
-
+// evalMemberExpression resolves `obj.name`. Modules expose their bindings and hashes their
+// string keys, anything else is looked up in the methods available for its type
+func evalMemberExpression(obj object.Object, name string) object.Object {
+	switch obj := obj.(type) {
+	case *object.Module:
+		return evalModuleIndexExpression(obj, &object.String{Value: name})
+	case *object.Hash:
+		if value, ok := obj.Get(&object.String{Value: name}); ok {
+			return value
+		}
+	}
+	if method, ok := methods.Lookup(obj, name); ok {
+		return method
+	}
+	if obj.Type() == object.HASH_OBJ {
+		return object.NULL
 	}
+	return object.NewError("undefined method %s for %s", name, obj.Type())
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
-	env := object.NewEnclosedEnvironment(fn.Env)
-	for i, param := range fn.Parameters {
-		env.Set(param.Value, args[i])
-	}
-	return env
+func applyFunction(fn object.Object, args []object.Object) object.Object {
+	return callFunction(fn, args, nil)
+}
+
+// apply is the object.Applier handed to builtins
+func apply(fn object.Object, args ...object.Object) object.Object {
+	return applyFunction(fn, args)
 }
 
 func unwrapReturnValue(o object.Object) object.Object {
@@ -211,7 +280,7 @@ func evalIdentifier(ident *ast.Identifier, env *object.Environment) object.Objec
 		return builtin
 	}
 
-	return object.NewError("identifier not found: " + ident.Value)
+	return object.NewError("identifier not found: %s", ident.Value)
 }
 
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
@@ -219,7 +288,7 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	if isError(cond) {
 		return cond
 	}
-	if isTruthy(cond) {
+	if object.IsTruthy(cond) {
 		return Eval(ie.Consequence, env)
 	} else if ie.Alternative != nil {
 		return Eval(ie.Alternative, env)
@@ -228,62 +297,73 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	return object.NULL
 }
 
+// evalTemplateLiteral renders each embedded expression with Inspect and joins it with the literal text
+func evalTemplateLiteral(node *ast.TemplateLiteral, env *object.Environment) object.Object {
+	var out bytes.Buffer
+	for _, part := range node.Parts {
+		val := Eval(part, env)
+		if isError(val) {
+			return val
+		}
+		out.WriteString(val.Inspect())
+	}
+	return &object.String{Value: out.String()}
+}
+
 func evalHashLiteral(node *ast.HashLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.HashPair)
+	hash := object.NewHash()
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
 		if isError(key) {
 			return key
 		}
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
-			return object.NewError("unusable as hash key: %s", key.Type())
+		hashKey, err := HashKey(key)
+		if err != nil {
+			err.Pos = pair.Key.Pos()
+			return err
 		}
-		value := Eval(valueNode, env)
+		value := Eval(pair.Value, env)
 		if isError(value) {
 			return value
 		}
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.HashPair{Key: key, Value: value}
+		hash.Set(hashKey, value)
 	}
-	return &object.Hash{Pairs: pairs}
+	return hash
 }
 
-func isTruthy(o object.Object) bool {
-	switch o {
-	case object.NULL:
-		return false
-	case object.TRUE:
-		return true
-	case object.FALSE:
-		return false
-	default:
-		return true
+// HashKey converts key into the form it is stored under in a hash, failing for
+// values that cannot be used as hash keys
+func HashKey(key object.Object) (object.Hashable, *object.Error) {
+	hashKey, ok := object.ToHashable(key)
+	if !ok {
+		return nil, object.NewError("unusable as hash key: %s", key.Type())
 	}
+	return hashKey, nil
 }
 
-func evalPrefixExpression(op string, right object.Object) object.Object {
+func evalPrefixExpression(op string, right object.Object, ops *Operators) object.Object {
 	switch op {
 	case "!":
 		return evalBangOperatorExpression(right)
 	case "-":
-		return evalMinusOperatorExpression(right)
+		return evalMinusOperatorExpression(right, ops.options.CheckedArithmetic)
 	default:
 		return object.NewError("unknown operator: %s %s", op, right.Type())
 	}
 }
 
-func evalInfixExpression(op string, left, right object.Object) object.Object {
+func evalInfixExpression(op string, left, right object.Object, ops *Operators) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(op, left, right)
+		return evalIntegerInfixExpression(op, left, right, ops.options.CheckedArithmetic)
+	case object.IsNumber(left) && object.IsNumber(right):
+		// Mixing a float with an integer promotes the integer to a float
+		return evalFloatInfixExpression(op, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(op, left, right)
-	case op == "==":
-		return booleanObject(left == right)
-	case op == "!=":
-		return booleanObject(left != right)
+	case op == "==" || op == "!=":
+		return evalEqualityExpression(op, left, right, ops.comparer)
 	case left.Type() != right.Type():
 		return object.NewError("type mismatch: %s %s %s", left.Type(), op, right.Type())
 	default:
@@ -291,6 +371,39 @@ func evalInfixExpression(op string, left, right object.Object) object.Object {
 	}
 }
 
+// evalEqualityExpression compares values structurally, so arrays and hashes are equal
+// when their contents are
+func evalEqualityExpression(op string, left, right object.Object, comparer *object.Comparer) object.Object {
+	equal, err := comparer.Equal(left, right)
+	if err != nil {
+		return err
+	}
+	return booleanObject(equal == (op == "=="))
+}
+
+// evalLogicalExpression short circuits, only evaluating right when left does not already decide the result
+func evalLogicalExpression(op string, left object.Object, right ast.Expression, env *object.Environment) object.Object {
+	if op == "&&" && !object.IsTruthy(left) {
+		return object.FALSE
+	}
+	if op == "||" && object.IsTruthy(left) {
+		return object.TRUE
+	}
+	result := Eval(right, env)
+	if isError(result) {
+		return result
+	}
+	return booleanObject(object.IsTruthy(result))
+}
+
+// evalNullishExpression only evaluates right when left is null
+func evalNullishExpression(left object.Object, right ast.Expression, env *object.Environment) object.Object {
+	if left != object.NULL {
+		return left
+	}
+	return Eval(right, env)
+}
+
 func evalStringInfixExpression(op string, left, right object.Object) object.Object {
 	lVal := left.(*object.String).Value
 	rVal := right.(*object.String).Value
@@ -298,30 +411,73 @@ func evalStringInfixExpression(op string, left, right object.Object) object.Obje
 	case "+":
 		return &object.String{Value: lVal + rVal}
 	case "==":
-		return &object.Boolean{Value: lVal == rVal}
+		return booleanObject(lVal == rVal)
 	case "!=":
-		return &object.Boolean{Value: lVal != rVal}
+		return booleanObject(lVal != rVal)
 	default:
 		return object.NewError("unknown operator: %s %s %s", left.Type(), op, right.Type())
 	}
 }
 
-func evalIntegerInfixExpression(op string, left, right object.Object) object.Object {
+func evalIntegerInfixExpression(op string, left, right object.Object, checked bool) object.Object {
 	lVal := left.(*object.Integer).Value
 	rVal := right.(*object.Integer).Value
 	switch op {
 	case "+":
-		return &object.Integer{Value: lVal + rVal}
+		return addInts(lVal, rVal, checked)
+	case "-":
+		return subtractInts(lVal, rVal, checked)
+	case "*":
+		return multiplyInts(lVal, rVal, checked)
+	case "/":
+		return divideInts(lVal, rVal, checked)
+	case "%":
+		return moduloInts(lVal, rVal)
+	case "<":
+		return booleanObject(lVal < rVal)
+	case ">":
+		return booleanObject(lVal > rVal)
+	case "<=":
+		return booleanObject(lVal <= rVal)
+	case ">=":
+		return booleanObject(lVal >= rVal)
+	case "==":
+		return booleanObject(lVal == rVal)
+	case "!=":
+		return booleanObject(lVal != rVal)
+	default:
+		return object.NewError("unknown operator: %s %s %s", left.Type(), op, right.Type())
+	}
+}
+
+func evalFloatInfixExpression(op string, left, right object.Object) object.Object {
+	lVal := object.NumberValue(left)
+	rVal := object.NumberValue(right)
+	switch op {
+	case "+":
+		return &object.Float{Value: lVal + rVal}
 	case "-":
-		return &object.Integer{Value: lVal - rVal}
+		return &object.Float{Value: lVal - rVal}
 	case "*":
-		return &object.Integer{Value: lVal * rVal}
+		return &object.Float{Value: lVal * rVal}
 	case "/":
-		return &object.Integer{Value: lVal / rVal}
+		if rVal == 0 {
+			return object.NewError("division by zero: %s / %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: lVal / rVal}
+	case "%":
+		if rVal == 0 {
+			return object.NewError("modulo by zero: %s %% %s", left.Inspect(), right.Inspect())
+		}
+		return &object.Float{Value: math.Mod(lVal, rVal)}
 	case "<":
 		return booleanObject(lVal < rVal)
 	case ">":
 		return booleanObject(lVal > rVal)
+	case "<=":
+		return booleanObject(lVal <= rVal)
+	case ">=":
+		return booleanObject(lVal >= rVal)
 	case "==":
 		return booleanObject(lVal == rVal)
 	case "!=":
@@ -331,12 +487,15 @@ func evalIntegerInfixExpression(op string, left, right object.Object) object.Obj
 	}
 }
 
-func evalMinusOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+func evalMinusOperatorExpression(right object.Object, checked bool) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		return negateInt(right.Value, checked) // notice the flipping of the value here
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return object.NewError("unknown operator: -%s", right.Type())
 	}
-	v := right.(*object.Integer).Value
-	return &object.Integer{Value: -v} // notice the flipping of the value here
 }
 
 func evalBangOperatorExpression(right object.Object) object.Object {
@@ -1,6 +1,7 @@
 package evaluator
 
 import (
+	"bellamy/ast"
 	"bellamy/lexer"
 	"bellamy/object"
 	"bellamy/parser"
@@ -37,12 +38,106 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestArithmeticErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"7 % 3", "1"},
+		{"-7 % 3", "-1"},
+		{"7.5 % 2", "1.5"},
+		{"let x = 10; x %= 4; x", "2"},
+		{"1 / 0", "ERROR: 1:3: division by zero: 1 / 0"},
+		{"5 % 0", "ERROR: 1:3: modulo by zero: 5 % 0"},
+		{"1.5 / 0", "ERROR: 1:5: division by zero: 1.5 / 0"},
+		{"let f = fn(x) {\n  10 / x\n}; f(0)", "ERROR: 2:6: division by zero: 10 / 0"},
+		{"9223372036854775807 + 1", "-9223372036854775808"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestCheckedArithmetic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807 + 1", "ERROR: 1:21: integer overflow: 9223372036854775807 + 1"},
+		{"9223372036854775807 + -1", "9223372036854775806"},
+		{"-9223372036854775807 - 2", "ERROR: 1:22: integer overflow: -9223372036854775807 - 2"},
+		{"0 - 9223372036854775807 - 1", "-9223372036854775808"},
+		{"4611686018427387904 * 2", "ERROR: 1:21: integer overflow: 4611686018427387904 * 2"},
+		{"4611686018427387903 * 2", "9223372036854775806"},
+		{"let min = 0 - 9223372036854775807 - 1; min / -1", "ERROR: 1:44: integer overflow: -9223372036854775808 / -1"},
+		{"let min = 0 - 9223372036854775807 - 1; -min", "ERROR: 1:40: integer overflow: -(-9223372036854775808)"},
+		{"let min = 0 - 9223372036854775807 - 1; min * -1", "ERROR: 1:44: integer overflow: -9223372036854775808 * -1"},
+		{"6 * 7", "42"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalWithOptions(tt.input, Options{CheckedArithmetic: true})
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"3.14", "3.14"},
+		{"-2.5", "-2.5"},
+		{"1.5 + 1.5", "3.0"},
+		{"1 + 0.5", "1.5"},
+		{"0.5 * 4", "2.0"},
+		{"7 / 2.0", "3.5"},
+		{"7 / 2", "3"},
+		{"10 - 0.25", "9.75"},
+		{"1.5 < 2", "true"},
+		{"2 >= 2.0", "true"},
+		{"1 == 1.0", "true"},
+		{"0.1 + 0.2 == 0.3", "false"},
+		{"let x = 1; x += 0.5; x", "1.5"},
+		{`{1.5: "a"}[1.5]`, "a"},
+		{"int(3.9)", "3"},
+		{"int(-3.9)", "-3"},
+		{`int(" 42 ")`, "42"},
+		{"float(2)", "2.0"},
+		{`float("2.5")`, "2.5"},
+		{"round(2.5)", "3"},
+		{"floor(-2.5)", "-3"},
+		{"ceil(2.1)", "3"},
+		{"round(4)", "4"},
+		{"2.7.floor()", "2"},
+		{"abs(-1.5)", "1.5"},
+		{"sort([2, 1.5, 3])", "[1.5, 2, 3]"},
+		{`int("abc")`, "ERROR: 1:4: could not convert \"abc\" to INTEGER"},
+		{"1.5 + true", "ERROR: 1:5: type mismatch: FLOAT + BOOLEAN"},
+		{"round(true)", "ERROR: 1:6: argument to `round` must be INTEGER or FLOAT, got BOOLEAN"},
+		{`int(float("1e36"))`, "ERROR: 1:4: 1e+36 is out of range for INTEGER"},
+		{`round(-float("1e36"))`, "ERROR: 1:6: -1e+36 is out of range for INTEGER"},
+		{`int(float("NaN"))`, "ERROR: 1:4: NaN is out of range for INTEGER"},
+		{`floor(float("-Inf"))`, "ERROR: 1:6: -Inf is out of range for INTEGER"},
+		{`int(-9223372036854775807.0 - 1)`, "-9223372036854775808"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
 func TestEvalStringExpression(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected string
 	}{
 		{`"yo dawg, i heard you liked strings"`, "yo dawg, i heard you liked strings"},
+		{`"10 \"quoted\" 20\n"`, "10 \"quoted\" 20\n"},
+		{"`C:\\raw\\path`", "C:\\raw\\path"},
 	}
 
 	for _, tt := range tests {
@@ -51,6 +146,29 @@ func TestEvalStringExpression(t *testing.T) {
 	}
 }
 
+func TestStringInterpolation(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let count = 21; "total: ${count * 2}"`, "total: 42"},
+		{`"${1.5} ${true} ${[1, "a"]} ${"str"}"`, "1.5 true [1, a] str"},
+		{`let name = "world"; "hello ${name.upper()}!\n"`, "hello WORLD!\n"},
+		{`let h = {"k": "v"}; "${h["k"]}${h["k"]}"`, "vv"},
+		{`"outer ${"inner ${1 + 1}"}"`, "outer inner 2"},
+		{`"\${not} interpolated"`, "${not} interpolated"},
+	}
+
+	for _, tt := range tests {
+		testStringObject(t, testEval(tt.input), tt.expected)
+	}
+
+	errObj, ok := testEval(`"a ${1 + true} b"`).(*object.Error)
+	assert.True(t, ok)
+	assert.Equal(t, "type mismatch: INTEGER + BOOLEAN", errObj.Message)
+	assert.Equal(t, "1:8", errObj.Pos.String())
+}
+
 func TestStringConcat(t *testing.T) {
 	input := `"hello" + " " + "world!"`
 	evaluated := testEval(input)
@@ -81,6 +199,21 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 < 2) == false", false},
 		{"(1 > 2) == true", false},
 		{"(1 > 2) == false", true},
+		{"1 <= 2", true},
+		{"2 <= 2", true},
+		{"3 <= 2", false},
+		{"1 >= 2", false},
+		{"2 >= 2", true},
+		{"true && true", true},
+		{"true && false", false},
+		{"false || true", true},
+		{"false || false", false},
+		{"1 < 2 && 2 < 3", true},
+		{"1 > 2 || 2 > 3", false},
+		{"5 && !5", false},
+		{"false && (1 + true)", false},
+		{"true || foobar", true},
+		{"let called = false; let f = fn() { called = true }; false && f(); called", false},
 	}
 
 	for _, tt := range tests {
@@ -120,6 +253,7 @@ func TestIfElseExpressions(t *testing.T) {
 		{"if (1 > 2) { 10 }", nil},
 		{"if (1 > 2) { 10 } else { 20 }", 20},
 		{"if (1 < 2) { 10 } else { 20 }", 10},
+		{`if ("a" == "b") { 10 } else { 20 }`, 20},
 	}
 
 	for _, tt := range tests {
@@ -253,6 +387,144 @@ if (10 > 1) {
 	}
 }
 
+func TestRecoverPanics(t *testing.T) {
+	env := object.NewEnvironment()
+	env.Set("boom", &object.Builtin{Fn: func(object.Applier, ...object.Object) object.Object {
+		panic("boom")
+	}})
+
+	program := parser.New(lexer.New("map([1], fn(x) { boom() })")).ParseProgram()
+	assert.Equal(t, "ERROR: 1:1: internal error: boom", Eval(program, env).Inspect())
+}
+
+func TestCallDepth(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(10000000)", "ERROR: 1:47: stack overflow: calls nested more than 65536 deep"},
+		{"let f = fn(n) { if (n == 0) { 0 } else { 1 + map([n], fn(x) { f(x - 1) })[0] } }; f(10000000)", "ERROR: 1:49: stack overflow: calls nested more than 65536 deep"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+
+	// The calls that failed no longer count towards the depth
+	env := object.NewEnvironment()
+	testEvalIn := func(input string) object.Object {
+		return Eval(parser.New(lexer.New(input)).ParseProgram(), env)
+	}
+	testEvalIn("let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }")
+	assert.Equal(t, "ERROR: 1:47: stack overflow: calls nested more than 65536 deep", testEvalIn("f(65536)").Inspect())
+	assert.Equal(t, "65535", testEvalIn("f(65535)").Inspect())
+}
+
+func TestErrorPositions(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedPos string
+	}{
+		{"5 + true;", "1:3"},
+		{"let x = 1;\nlet y = x - foobar;", "2:13"},
+		{"let f = fn(x) {\n  x + true\n};\nf(1);", "2:5"},
+		{"let a = [1];\n  len(a, a);", "2:6"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expectedPos, errObj.Pos.String())
+	}
+}
+
+func TestLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let f = fn() { for (x in [1, 2, 3, 4]) { if (x > 2) { return x; } } }; f()", 3},
+		{`let f = fn() { for (c in "abc") { if (c == "b") { return c; } } }; f()`, "b"},
+		{`let f = fn() { for (k in {"a": 1}) { return k; } }; f()`, "a"},
+		{"let f = fn() { for (x in [1, 2, 3]) { if (x < 3) { continue; } return x; } }; f()", 3},
+		{"let f = fn() { for (x in range(100000)) { if (x == 99999) { return x; } } }; f()", 99999},
+		{"let f = fn() { while (true) { for (x in [1]) { break; } return 7; } }; f()", 7},
+		{"let fns = []; for (x in [1, 2]) { let fns = 1 }; fns", "[]"},
+		{"let fns = []; let i = 0; while (i < 3) { let j = i; fns = push(fns, fn() { j }); i += 1; }; map(fns, fn(f) { f() })", "[0, 1, 2]"},
+		{"let i = 0; while (i < 1) { let j = 5; i += 1; }; j", "ERROR: 1:50: identifier not found: j"},
+		{"for (x in [1, 2]) { x }", nil},
+		{"while (false) { 1 }", nil},
+		{"while (true) { break; }", nil},
+		{"for (x in 5) { x }", "ERROR: 1:11: cannot iterate over INTEGER"},
+		{"for (x in [1]) { x }; x", "ERROR: 1:23: identifier not found: x"},
+		{"while (1 + true) { 1 }", "ERROR: 1:10: type mismatch: INTEGER + BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if _, ok := evaluated.(*object.String); ok {
+				testStringObject(t, evaluated, expected)
+			} else {
+				assert.Equal(t, expected, evaluated.Inspect())
+			}
+		case nil:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
+func TestAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 1; x = 2; x", "2"},
+		{"let x = 1; let y = 1; x = y = 5; x + y", "10"},
+		{"let x = 10; x += 5; x -= 3; x *= 2; x /= 4; x", "6"},
+		{`let s = "a"; s += "b"; s`, "ab"},
+		{"let count = 0; let inc = fn() { count += 1 }; inc(); inc(); count", "2"},
+		{"let f = fn() { let x = 1; let g = fn() { x = x + 1 }; g(); x }; f()", "2"},
+		{"let x = 1; let f = fn() { let x = 5; x = 6 }; f(); x", "1"},
+		{"let i = 0; let total = 0; while (i < 5) { i += 1; if (i == 2) { continue; } total += i; }; total", "13"},
+		{"let sum = 0; for (x in range(1, 101)) { sum += x }; sum", "5050"},
+		{"let a = [1, 2, 3]; a[1] = 5; a", "[1, 5, 3]"},
+		{"let a = [1, 2, 3]; a[0] += 10; a[0]", "11"},
+		{`let h = {"a": 1}; h["b"] = 2; h["a"] *= 3; [h["a"], h["b"]]`, "[3, 2]"},
+		{`let h = {"n": 1}; h.n += 1; h.m = 5; [h.n, h.m]`, "[2, 5]"},
+		{`let a = [1]; a[0] = a; a`, "[[...]]"},
+		{`let h = {}; h.self = h; let a = [h]; h.list = a; "${a}"`, "[{self: {...}, list: [...]}]"},
+		{"y = 5", "ERROR: 1:1: assignment to undeclared variable: y"},
+		{"let a = [1]; a[1] = 2", "ERROR: 1:19: index out of bounds of array, i=1, a=[1]"},
+		{"let x = 1; x += true", "ERROR: 1:14: type mismatch: INTEGER + BOOLEAN"},
+		{`let h = {}; h[fn(x) { x }] = 1`, "ERROR: 1:28: unusable as hash key: FUNCTION"},
+		{"let s = 1; s[0] = 1", "ERROR: 1:17: index assignment not supported: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `
+// add two numbers
+let add = fn(a, b) {
+	a + b // the sum
+};
+/* a /* nested */ block */
+add(1, /* inline */ 2);`
+
+	testIntegerObject(t, testEval(input), 3)
+}
+
 func TestLetStatements(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -353,6 +625,86 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
+func TestHigherOrderBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`map([1, 2, 3], fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`[1, 2, 3].map(fn(x) { x * 2 })`, "[2, 4, 6]"},
+		{`map([], fn(x) { x * 2 })`, "[]"},
+		{`filter([1, 2, 3, 4], fn(x) { x > 2 })`, "[3, 4]"},
+		{`reduce([1, 2, 3, 4], fn(acc, x) { acc + x })`, "10"},
+		{`[1, 2, 3].reduce(fn(acc, x) { acc + x }, 10)`, "16"},
+		{`reduce([], fn(acc, x) { acc + x }, 0)`, "0"},
+		{`sort([3, 1, 2])`, "[1, 2, 3]"},
+		{`sort(["b", "c", "a"])`, "[a, b, c]"},
+		{`[3, 1, 2].sort(fn(a, b) { a > b })`, "[3, 2, 1]"},
+		{`let a = [3, 1, 2]; a.sort(); a`, "[3, 1, 2]"},
+		{`find([1, 2, 3], fn(x) { x > 1 })`, "2"},
+		{`find([1, 2, 3], fn(x) { x > 5 })`, "null"},
+		{`any([1, 2, 3], fn(x) { x == 2 })`, "true"},
+		{`any([], fn(x) { true })`, "false"},
+		{`all([1, 2, 3], fn(x) { x > 0 })`, "true"},
+		{`[1, 2, 3].all(fn(x) { x > 1 })`, "false"},
+		{`zip([1, 2, 3], ["a", "b"])`, "[[1, a], [2, b]]"},
+		{`range(3)`, "[0, 1, 2]"},
+		{`range(2, 5)`, "[2, 3, 4]"},
+		{`range(5, 0, 0 - 2)`, "[5, 3, 1]"},
+		{`range(9223372036854775806, 9223372036854775807, 2)`, "[9223372036854775806]"},
+		{`range(0 - 9223372036854775807, 0 - 9223372036854775807 - 1, 0 - 2)`, "[-9223372036854775807]"},
+		{`flatten([1, [2, 3], [[4]]])`, "[1, 2, 3, [4]]"},
+		{`range(1, 6).filter(fn(x) { x != 3 }).map(fn(x) { x * x }).reduce(fn(a, b) { a + b })`, "46"},
+		{`map([1, 2], fn(x) { x + true })`, "ERROR: 1:23: type mismatch: INTEGER + BOOLEAN"},
+		{`map([1, 2], 5)`, "ERROR: 1:4: not a function: INTEGER"},
+		{`reduce([], fn(acc, x) { acc + x })`, "ERROR: 1:7: `reduce` of empty ARRAY with no initial value"},
+		{`sort([1, "a"])`, "ERROR: 1:5: `sort` cannot compare STRING and INTEGER without a comparator"},
+		{`sort([1, 2], fn(a, b) { 1 })`, "ERROR: 1:5: `sort` comparator must return BOOLEAN, got INTEGER"},
+		{`range(1, 2, 0)`, "ERROR: 1:6: `range` step must not be 0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestMethodCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`[1, 2, 3].len()`, 3},
+		{`[1, 2, 3].push(4).last()`, 4},
+		{`let a = [5, 6]; a.first() + a.tail().first()`, 11},
+		{`"Bellamy".upper()`, "BELLAMY"},
+		{`"Bellamy".lower().len()`, 7},
+		{`let n = 0 - 7; n.abs()`, 7},
+		{`let h = {"one": 1, "add": fn(x, y) { x + y }}; h.add(h.one, 2)`, 3},
+		{`let h = {"one": 1}; h.two`, nil},
+		{`let f = [1, 2].push; f(3).len()`, 3},
+		{`"abc".map(fn(x) { x })`, "undefined method map for STRING"},
+		{`true.len()`, "undefined method len for BOOLEAN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			if errObj, ok := evaluated.(*object.Error); ok {
+				assert.Equal(t, expected, errObj.Message)
+			} else {
+				testStringObject(t, evaluated, expected)
+			}
+		case nil:
+			testNullObject(t, evaluated)
+		}
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2*2, 3+ 3]"
 	evaluated := testEval(input)
@@ -441,21 +793,21 @@ func TestHashLiterals(t *testing.T) {
 	result, ok := evaluated.(*object.Hash)
 	assert.True(t, ok)
 
-	expected := map[object.HashKey]int64{
-		(&object.String{Value: "one"}).HashKey():   1,
-		(&object.String{Value: "two"}).HashKey():   2,
-		(&object.String{Value: "three"}).HashKey(): 3,
-		(&object.Integer{Value: 4}).HashKey():      4,
-		object.TRUE.HashKey():                      5,
-		object.FALSE.HashKey():                     6,
+	expected := map[object.Hashable]int64{
+		&object.String{Value: "one"}:   1,
+		&object.String{Value: "two"}:   2,
+		&object.String{Value: "three"}: 3,
+		&object.Integer{Value: 4}:      4,
+		object.TRUE:                    5,
+		object.FALSE:                   6,
 	}
 
-	assert.Equal(t, len(expected), len(result.Pairs))
+	assert.Equal(t, len(expected), result.Len())
 
 	for expectedKey, expectedValue := range expected {
-		pair, ok := result.Pairs[expectedKey]
+		value, ok := result.Get(expectedKey)
 		assert.True(t, ok)
-		testIntegerObject(t, pair.Value, expectedValue)
+		testIntegerObject(t, value, expectedValue)
 	}
 }
 
@@ -510,12 +862,21 @@ func testNullObject(t *testing.T, o object.Object) {
 }
 
 func testEval(input string) object.Object {
+	return testEvalWithOptions(input, Options{})
+}
+
+func testEvalWithOptions(input string, options Options) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
-	env := object.NewEnvironment()
 
-	return Eval(program, env)
+	return runProgram(program, options)
+}
+
+// runProgram runs the programs of the tests. The tests of the vm swap it out to run
+// the same programs on the vm instead.
+var runProgram = func(program *ast.Program, options Options) object.Object {
+	return Eval(program, NewEnvironment(options))
 }
 
 func testBooleanObject(t *testing.T, o object.Object, expected bool) {
@@ -537,3 +898,324 @@ func assertObjectValue(t *testing.T, ok bool, val interface{}, expected interfac
 	assert.True(t, ok)
 	assert.Equal(t, expected, val)
 }
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`split("a,b,c", ",")`, "[a, b, c]"},
+		{`"  a  b ".split()`, "[a, b]"},
+		{`join(["a", "b", "c"], "-")`, "a-b-c"},
+		{`["a", "b"].join()`, "ab"},
+		{`trim("  hi  ")`, "hi"},
+		{`"xxhixx".trim("x")`, "hi"},
+		{`trimLeft("  hi  ")`, "hi  "},
+		{`"  hi  ".trimRight()`, "  hi"},
+		{`contains("hello", "ell")`, "true"},
+		{`"hello".contains("z")`, "false"},
+		{`startsWith("hello", "he")`, "true"},
+		{`"hello".endsWith("lo")`, "true"},
+		{`indexOf("hello", "l")`, "2"},
+		{`"hello".indexOf("z")`, "-1"},
+		{`replace("a-b-c", "-", "+")`, "a+b+c"},
+		{`upper("hi")`, "HI"},
+		{`"HI".lower()`, "hi"},
+		{`repeat("ab", 3)`, "ababab"},
+		{`substring("hello", 1, 3)`, "el"},
+		{`"hello".substring(2)`, "llo"},
+		{`chars("abc")`, "[a, b, c]"},
+		{`"hello"[1]`, "e"},
+		{`let s = "hello"; s[len(s) - 1]`, "o"},
+		{`"hello"[1:3]`, "el"},
+		{`"hello"[:2]`, "he"},
+		{`"hello"[3:]`, "lo"},
+		{`[1, 2, 3, 4][1:3]`, "[2, 3]"},
+		{`[1, 2, 3][:]`, "[1, 2, 3]"},
+		{`join(["a", 1], ",")`, "ERROR: 1:5: `join` requires an ARRAY of STRING, got INTEGER at index 1"},
+		{`"abc".contains(1)`, "ERROR: 1:15: argument to `contains` must be STRING, got INTEGER"},
+		{`repeat("a", 0 - 1)`, "ERROR: 1:7: negative count for `repeat`: -1"},
+		{`substring("abc", 2, 5)`, "ERROR: 1:10: substring bounds out of range [2:5] with length 3"},
+		{`"abc"[3]`, "ERROR: 1:6: index out of bounds of string, i=3, len=3"},
+		{`"abc"[2:1]`, "ERROR: 1:6: slice bounds out of range [2:1] with length 3"},
+		{`"abc"["a":]`, "ERROR: 1:6: slice index must be INTEGER, got STRING"},
+		{`5[1:2]`, "ERROR: 1:2: slice operator not supported: INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestUnicodeStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`len("héllo")`, "5"},
+		{`"日本語".len()`, "3"},
+		{`"héllo"[1]`, "é"},
+		{`"日本語"[2]`, "語"},
+		{`"héllo"[1:3]`, "él"},
+		{`"日本語"[1:]`, "本語"},
+		{`substring("héllo", 1, 2)`, "é"},
+		{`indexOf("日本語", "語")`, "2"},
+		{`chars("añb")`, "[a, ñ, b]"},
+		{`bytes("é")`, "[195, 169]"},
+		{`runes("é!")`, "[233, 33]"},
+		{`"aé".bytes().len()`, "3"},
+		{`let out = ""; for (c in "añb") { out = c + out }; out`, "bña"},
+		{`let café = "☕"; café`, "☕"},
+		{`let 名前 = 1; 名前 + 1`, "2"},
+		{`let x1 = 0xFF; let x2 = 0b1_0000; x1 + x2`, "271"},
+		{`1_000 * 0o10`, "8000"},
+		{`"日本語"[3]`, "ERROR: 1:6: index out of bounds of string, i=3, len=3"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestNullAndOptionalAccess(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`null`, "null"},
+		{`null == null`, "true"},
+		{`let h = {"a": 1}; h["k"] == null`, "true"},
+		{`first([]) == null`, "true"},
+		{`5 == null`, "false"},
+		{`5 != null`, "true"},
+		{`!null`, "true"},
+		{`if (null) { 1 } else { 2 }`, "2"},
+		{`null ?? 5`, "5"},
+		{`0 ?? 5`, "0"},
+		{`false ?? 5`, "false"},
+		{`let h = {"port": 80}; h["host"] ?? "localhost"`, "localhost"},
+		{`null ?? null ?? 3`, "3"},
+		{`1 ?? missing`, "1"},
+		{`let h = null; h?.port`, "null"},
+		{`let h = {"port": 80}; h?.port`, "80"},
+		{`let h = null; h?["port"]`, "null"},
+		{`let h = null; h?.server.port`, "null"},
+		{`let h = null; h?.servers[0].port()`, "null"},
+		{`let h = {"servers": null}; h.servers?[0]`, "null"},
+		{`let s = null; s?[1:]`, "null"},
+		{`let h = null; h?.port ?? 8080`, "8080"},
+		{`let h = {"db": {"port": 5432}}; h?.db?.port`, "5432"},
+		{`let a = null; a?.len()`, "null"},
+		{`"abc"?.upper()`, "ABC"},
+		{`let h = {"a": null}; h.a.b`, "ERROR: 1:25: undefined method b for NULL"},
+		{`let h = null; h?.a ?? missing`, "ERROR: 1:23: identifier not found: missing"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1, 2] == [1, 2]`, "true"},
+		{`[1, 2] != [1, 2]`, "false"},
+		{`[1, 2] == [2, 1]`, "false"},
+		{`[1, 2] == [1, 2, 3]`, "false"},
+		{`[1, [2, [3]]] == [1, [2, [3]]]`, "true"},
+		{`[1, "a", true, null] == [1.0, "a", true, null]`, "true"},
+		{`{"a": 1, "b": [1, 2]} == {"b": [1, 2], "a": 1}`, "true"},
+		{`{"a": 1} == {"a": 2}`, "false"},
+		{`{"a": 1} != {"b": 1}`, "true"},
+		{`{"a": 1} == [1]`, "false"},
+		{`[] == {}`, "false"},
+		{`let f = fn() {}; [f] == [f]`, "true"},
+		{`[fn() {}] == [fn() {}]`, "false"},
+		{`let a = [1]; a[0] = a; let b = [1]; b[0] = b; a == b`, "true"},
+		{`let v = fn(id, name) { {"id": id, "name": name, "equals": fn(a, b) { a["id"] == b["id"] }} }; v(1, "x") == v(1, "y")`, "true"},
+		{`let v = fn(id) { {"id": id, "equals": fn(a, b) { a["id"] == b["id"] }} }; [v(1), v(2)] == [v(1), v(3)]`, "false"},
+		{`let v = {"id": 1, "equals": fn(a, b) { a == b }}; v == v`, "true"},
+		{`let v = {"id": 1, "equals": fn(a, b) { a == b }}; v == {"id": 1, "equals": v["equals"]}`, "true"},
+		{`let v = {"id": 1, "equals": fn(a, b) { a == b }}; v == {"id": 2, "equals": v["equals"]}`, "false"},
+		{`let v = {"equals": fn(a, b) { true }}; v == 5`, "true"},
+		{`let v = {"equals": fn(a, b) { a + b }}; v != {}`, "ERROR: 1:33: unknown operator: HASH + HASH"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestCompositeHashKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{[1, 2]: "x"}[[1, 2]]`, "x"},
+		{`{[1, 2]: "x"}[[2, 1]]`, "null"},
+		{`{[1, 2]: "x"}`, "{(1, 2): x}"},
+		{`let h = {}; h[["a", 1]] = 1; h[["a", 1]] += 1; h[["a", 1]]`, "2"},
+		{`let k = [1, 2]; let h = {k: "x"}; k[0] = 5; h[[1, 2]]`, "x"},
+		{`let h = {[[1], true]: "nested"}; h[[[1], true]]`, "nested"},
+		{`{tuple(1, 2): "t"}[[1, 2]]`, "t"},
+		{`let t = tuple(1, [2, 3]); t`, "(1, (2, 3))"},
+		{`let t = tuple(1, "a"); t[1]`, "a"},
+		{`tuple(1, 2).len()`, "2"},
+		{`len(tuple())`, "0"},
+		{`let s = 0; for (x in tuple(1, 2, 3)) { s += x }; s`, "6"},
+		{`tuple(1, 2) == tuple(1, 2)`, "true"},
+		{`tuple(1, 2) == [1, 2]`, "false"},
+		{`{1: "int", 1.0: "float"}[1]`, "int"},
+		{`{1: "int", 1.0: "float"}[1.0]`, "float"},
+		{`let count = {}; for (p in [[1, "a"], [2, "b"], [1, "a"]]) { count[p] = (count[p] ?? 0) + 1 }; count[[1, "a"]]`, "2"},
+		{`{[fn() {}]: 1}`, "ERROR: 1:2: unusable as hash key: ARRAY"},
+		{`let a = [1]; a[0] = a; {a: 1}`, "ERROR: 1:25: unusable as hash key: ARRAY"},
+		{`tuple({})`, "ERROR: 1:6: argument to `tuple` must be usable as a hash key, got HASH"},
+		{`tuple(1)[1]`, "ERROR: 1:9: index out of bounds of tuple, i=1, t=(1)"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestHashOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"z": 1, "a": 2, "m": 3}`, "{z: 1, a: 2, m: 3}"},
+		{`{3: "c", 1: "a", 2: "b", true: "t"}`, "{3: c, 1: a, 2: b, true: t}"},
+		{`let h = {"b": 1}; h["a"] = 2; h["c"] = 3; h["b"] = 4; h`, "{b: 4, a: 2, c: 3}"},
+		{`let h = {"x": 1, "y": 2, "x": 3}; h`, "{x: 3, y: 2}"},
+		{`let ks = ""; for (k in {"q": 1, "w": 2, "e": 3}) { ks += k }; ks`, "qwe"},
+		{`let log = []; let k = fn(x) { log = push(log, x); x }; {k("b"): k(1), k("a"): k(2)}; log`, "[b, 1, a, 2]"},
+		{`{"x": {"b": 1, "a": 2}, "y": [1, 2]}`, "{x: {b: 1, a: 2}, y: [1, 2]}"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestHashBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`keys({"b": 1, "a": 2})`, "[b, a]"},
+		{`{"b": 1, "a": 2}.values()`, "[1, 2]"},
+		{`entries({"b": 1, "a": 2})`, "[[b, 1], [a, 2]]"},
+		{`keys({})`, "[]"},
+		{`keys({[1, 2]: true})`, "[(1, 2)]"},
+		{`len({"a": 1, "b": 2})`, "2"},
+		{`{}.len()`, "0"},
+		{`has({"a": null}, "a")`, "true"},
+		{`{"a": 1}.has("b")`, "false"},
+		{`has({[1, 2]: 1}, [1, 2])`, "true"},
+		{`delete({"a": 1, "b": 2, "c": 3}, "b")`, "{a: 1, c: 3}"},
+		{`delete({"a": 1, "b": 2, "c": 3}, "a", "c", "z")`, "{b: 2}"},
+		{`let h = {"a": 1, "b": 2}; let d = h.delete("a"); [h, d]`, "[{a: 1, b: 2}, {b: 2}]"},
+		{`let h = {"a": 1, "b": 2, "c": 3}.delete("a"); h["c"] = 4; h["a"] = 5; h`, "{b: 2, c: 4, a: 5}"},
+		{`merge({"a": 1, "b": 2}, {"b": 3, "c": 4})`, "{a: 1, b: 3, c: 4}"},
+		{`let h = {"a": 1}; let m = h.merge({"b": 2}); [h, m]`, "[{a: 1}, {a: 1, b: 2}]"},
+		{`merge({"a": 1}, {"b": 2}, {"a": 3})`, "{a: 3, b: 2}"},
+		{`let s = 0; for (e in entries({"a": 1, "b": 2})) { s += e[1] }; s`, "3"},
+		{`let h = {"keys": "shadowed"}; h.keys`, "shadowed"},
+		{`keys([1])`, "ERROR: 1:5: argument to `keys` must be HASH, got ARRAY"},
+		{`has({}, fn() {})`, "ERROR: 1:4: `has` key is unusable as hash key: FUNCTION"},
+		{`merge({}, 1)`, "ERROR: 1:6: argument to `merge` must be HASH, got INTEGER"},
+		{`delete({})`, "ERROR: 1:7: wrong number of arguments. got 1, expected at least 2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestFunctionArity(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let add = fn(a, b) { a + b }; add(1)", "ERROR: 1:34: wrong number of arguments to function `add`. got 1, expected 2"},
+		{"let add = fn(a, b) { a + b }; add(1, 2, 3)", "ERROR: 1:34: wrong number of arguments to function `add`. got 3, expected 2"},
+		{"fn(x) { x }()", "ERROR: 1:12: wrong number of arguments to anonymous function. got 0, expected 1"},
+		{"let f = fn() { 1 };\nlet g = fn() { f(2) };\ng()", "ERROR: 2:17: wrong number of arguments to function `f`. got 1, expected 0"},
+		{"map([1, 2], fn(a, b) { a })", "ERROR: 1:4: wrong number of arguments to anonymous function. got 1, expected 2"},
+		{"let h = {\"f\": fn(x) { x }}; h.f()", "ERROR: 1:32: wrong number of arguments to anonymous function. got 0, expected 1"},
+		{"let add = fn(a, b) { a + b }; add(1, 2)", "3"},
+		{"let f = fn() { 7 }; f()", "7"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestParameterForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let f = fn(a, b = 2) { a + b }; f(1)", "3"},
+		{"let f = fn(a, b = 2) { a + b }; f(1, 5)", "6"},
+		{"let f = fn(a, b = a * 10) { a + b }; f(1)", "11"},
+		{"let n = 0; let f = fn(a = n + 1) { a }; n = 5; f()", "6"},
+		{"let f = fn(first, ...rest) { rest }; f(1, 2, 3)", "[2, 3]"},
+		{"let f = fn(first, ...rest) { rest }; f(1)", "[]"},
+		{"let f = fn(...all) { len(all) }; f()", "0"},
+		{"let f = fn(a, b = 2, c = 3) { [a, b, c] }; f(1, c: 30)", "[1, 2, 30]"},
+		{"let f = fn(a, b) { a - b }; f(b: 1, a: 10)", "9"},
+		{"let f = fn(a, b = 2, ...rest) { [a, b, rest] }; f(1, 2, 3, 4)", "[1, 2, [3, 4]]"},
+		{"let f = fn(a, b = 2, ...rest) { [a, b, rest] }; f(a: 1)", "[1, 2, []]"},
+		{"let f = fn(a, b = 2) { a + b }; f()", "ERROR: 1:34: wrong number of arguments to function `f`. got 0, expected 1 to 2"},
+		{"let f = fn(a, b = 2) { a + b }; f(1, 2, 3)", "ERROR: 1:34: wrong number of arguments to function `f`. got 3, expected 1 to 2"},
+		{"let f = fn(a, ...r) { a }; f()", "ERROR: 1:29: wrong number of arguments to function `f`. got 0, expected at least 1"},
+		{"let f = fn(a, b) { a }; f(b: 1)", "ERROR: 1:26: missing argument a to function `f`"},
+		{"let f = fn(a) { a }; f(1, a: 2)", "ERROR: 1:23: argument a given more than once to function `f`"},
+		{"let f = fn(a) { a }; f(a: 1, a: 2)", "ERROR: 1:23: argument a given more than once to function `f`"},
+		{"let f = fn(a) { a }; f(a: 1, z: 2)", "ERROR: 1:23: unknown argument z to function `f`"},
+		{"let f = fn(...r) { r }; f(r: 1)", "ERROR: 1:26: rest parameter r of function `f` cannot be passed by name"},
+		{"let f = fn(a = missing) { a }; f()", "ERROR: 1:16: identifier not found: missing"},
+		{"len(x: 1)", "ERROR: 1:4: builtin functions do not take named arguments, got x"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
+
+func TestTailCalls(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let count = fn(n) { if (n == 0) { 0 } else { count(n - 1) } }; count(1000000)", "0"},
+		{"let count = fn(n, acc) { if (n == 0) { return acc; } return count(n - 1, acc + 1); }; count(1000000, 0)", "1000000"},
+		{"let even = fn(n) { if (n == 0) { true } else { odd(n - 1) } }; let odd = fn(n) { if (n == 0) { false } else { even(n - 1) } }; even(1000001)", "false"},
+		{"let count = fn(n) { if (n > 0) { return count(n - 1); } \"done\" }; count(1000000)", "done"},
+		{"let sum = fn(arr, i, acc = 0) { if (i == len(arr)) { acc } else { sum(arr, i + 1, acc: acc + arr[i]) } }; sum([1, 2, 3, 4], 0)", "10"},
+		{"let f = fn(n) { if (n == 0) { len(\"abc\") } else { f(n - 1) } }; f(10)", "3"},
+		{"let f = fn(n) { n }; return f(5); 10", "5"},
+		{"let f = fn(n) { if (n == 0) { g() } else { f(n - 1) } }; let g = fn() { 1 + true }; f(1000000)", "ERROR: 1:75: type mismatch: INTEGER + BOOLEAN"},
+		{"let f = fn(n) { if (n == 0) { f(1, 2) } else { f(n - 1) } }; f(1000000)", "ERROR: 1:32: wrong number of arguments to function `f`. got 2, expected 1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		assert.Equal(t, tt.expected, evaluated.Inspect(), tt.input)
+	}
+}
@@ -0,0 +1,61 @@
+package evaluator
+
+import (
+	"bellamy/ast"
+	"bellamy/object"
+	"testing"
+)
+
+// UseEngine runs the programs of the tests with run until restore is called
+func UseEngine(run func(*ast.Program, Options) object.Object) (restore func()) {
+	saved := runProgram
+	runProgram = run
+	return func() { runProgram = saved }
+}
+
+// EngineTests are the tests that hold for any engine running a program. TestFunctionObject
+// is left out as it looks into the functions the evaluator creates.
+var EngineTests = []struct {
+	Name string
+	Test func(*testing.T)
+}{
+	{"TestEvalIntegerExpression", TestEvalIntegerExpression},
+	{"TestArithmeticErrors", TestArithmeticErrors},
+	{"TestCheckedArithmetic", TestCheckedArithmetic},
+	{"TestEvalFloatExpression", TestEvalFloatExpression},
+	{"TestEvalStringExpression", TestEvalStringExpression},
+	{"TestStringInterpolation", TestStringInterpolation},
+	{"TestStringConcat", TestStringConcat},
+	{"TestEvalBooleanExpression", TestEvalBooleanExpression},
+	{"TestBangOperator", TestBangOperator},
+	{"TestIfElseExpressions", TestIfElseExpressions},
+	{"TestReturnStatements", TestReturnStatements},
+	{"TestErrorHandling", TestErrorHandling},
+	{"TestErrorPositions", TestErrorPositions},
+	{"TestLoops", TestLoops},
+	{"TestAssignment", TestAssignment},
+	{"TestComments", TestComments},
+	{"TestLetStatements", TestLetStatements},
+	{"TestFunctionApplication", TestFunctionApplication},
+	{"TestEnclosingEnvironments", TestEnclosingEnvironments},
+	{"TestClosures", TestClosures},
+	{"TestBuiltinFunctions", TestBuiltinFunctions},
+	{"TestHigherOrderBuiltins", TestHigherOrderBuiltins},
+	{"TestMethodCalls", TestMethodCalls},
+	{"TestArrayLiterals", TestArrayLiterals},
+	{"TestArrayIndexExpressions", TestArrayIndexExpressions},
+	{"TestHashLiterals", TestHashLiterals},
+	{"TestHashIndexExpressions", TestHashIndexExpressions},
+	{"TestStringBuiltins", TestStringBuiltins},
+	{"TestUnicodeStrings", TestUnicodeStrings},
+	{"TestNullAndOptionalAccess", TestNullAndOptionalAccess},
+	{"TestStructuralEquality", TestStructuralEquality},
+	{"TestCompositeHashKeys", TestCompositeHashKeys},
+	{"TestHashOrder", TestHashOrder},
+	{"TestHashBuiltins", TestHashBuiltins},
+	{"TestFunctionArity", TestFunctionArity},
+	{"TestParameterForms", TestParameterForms},
+	{"TestTailCalls", TestTailCalls},
+	{"TestImportExpressions", TestImportExpressions},
+	{"TestImportErrors", TestImportErrors},
+}
@@ -0,0 +1,107 @@
+package evaluator
+
+import (
+	"bellamy/ast"
+	"bellamy/lexer"
+	"bellamy/object"
+	"bellamy/parser"
+	"bellamy/token"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+func evalImportExpression(node *ast.ImportExpression, env *object.Environment) object.Object {
+	pathObj := Eval(node.Path, env)
+	if isError(pathObj) {
+		return pathObj
+	}
+
+	in := interpreterOf(env)
+	// Each module is evaluated in an environment of its own, run by the same interpreter
+	return in.modules.Import(pathObj, node.Pos(), func(program *ast.Program) (object.Bindings, object.Object) {
+		moduleEnv := object.NewEnvironment()
+		moduleEnv.SetContext(in)
+		return moduleEnv, Eval(program, moduleEnv)
+	})
+}
+
+// ModuleCache caches every imported module by its absolute path so that a file
+// is only ever run once, no matter how many times it is imported
+type ModuleCache struct {
+	modules map[string]*object.Module
+	// stack holds the absolute paths of the modules currently being run,
+	// innermost last, and is used to detect import cycles
+	stack []string
+}
+
+func NewModuleCache() *ModuleCache {
+	return &ModuleCache{modules: map[string]*object.Module{}}
+}
+
+// Import loads the module at pathObj, imported from the source position from. A module
+// that is not cached yet is parsed and handed to run, which returns its top level bindings
+// along with the result of running it.
+func (mc *ModuleCache) Import(pathObj object.Object, from token.Position, run func(*ast.Program) (object.Bindings, object.Object)) object.Object {
+	path, ok := pathObj.(*object.String)
+	if !ok {
+		return object.NewError("import path must be STRING, got %s", pathObj.Type())
+	}
+
+	// Relative imports are resolved against the directory of the importing file
+	file := path.Value
+	if !filepath.IsAbs(file) && from.File != "" {
+		file = filepath.Join(filepath.Dir(from.File), file)
+	}
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return object.NewError("could not import %q: %s", path.Value, err)
+	}
+
+	if mod, ok := mc.modules[abs]; ok {
+		return mod
+	}
+	for i, p := range mc.stack {
+		if p == abs {
+			cycle := append(mc.stack[i:len(mc.stack):len(mc.stack)], abs)
+			return object.NewError("import cycle detected: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	source, err := ioutil.ReadFile(abs)
+	if err != nil {
+		return object.NewError("could not import %q: %s", path.Value, err)
+	}
+
+	l := lexer.NewWithFile(string(source), file)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		return object.NewError("could not import %q:\n\t%s", path.Value, strings.Join(p.Errors(), "\n\t"))
+	}
+
+	mc.stack = append(mc.stack, abs)
+	defer func() { mc.stack = mc.stack[:len(mc.stack)-1] }()
+
+	env, result := run(program)
+	if isError(result) {
+		return result
+	}
+
+	mod := &object.Module{Name: path.Value, Env: env}
+	mc.modules[abs] = mod
+	return mod
+}
+
+func evalModuleIndexExpression(module, index object.Object) object.Object {
+	moduleObject := module.(*object.Module)
+	name, ok := index.(*object.String)
+	if !ok {
+		return object.NewError("module members must be accessed by STRING, got %s", index.Type())
+	}
+	val, ok := moduleObject.Env.Get(name.Value)
+	if !ok {
+		return object.NewError("module %s has no member %q", moduleObject.Name, name.Value)
+	}
+	return val
+}
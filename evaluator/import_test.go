@@ -0,0 +1,116 @@
+package evaluator
+
+import (
+	"bellamy/lexer"
+	"bellamy/object"
+	"bellamy/parser"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportExpressions(t *testing.T) {
+	dir := setupModules(t, map[string]string{
+		"lib/math.bel":  `let double = fn(x) { x * 2 }; let name = "math";`,
+		"lib/twice.bel": `let m = import("math.bel"); let quad = fn(x) { m["double"](m["double"](x)) };`,
+		"counter.bel":   `let counter = 0; let bump = fn() { counter = counter + 1; counter };`,
+	})
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let m = import("lib/math.bel"); m["double"](21)`, 42},
+		{`import("lib/math.bel")["name"]`, "math"},
+		{`let m = import("lib/math.bel"); m.double(m.double(1))`, 4},
+		{`import("lib/twice.bel")["quad"](3)`, 12},
+		{`import("lib/math.bel") == import("lib/math.bel")`, true},
+		{`let m = import("counter.bel"); m.bump(); m.bump(); m.counter`, 2},
+		{`import("lib/math.bel")["missing"]`, &object.Error{Message: "module lib/math.bel has no member \"missing\""}},
+		{`import(5)`, &object.Error{Message: "import path must be STRING, got INTEGER"}},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalFile(filepath.Join(dir, "main.bel"), tt.input)
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			testStringObject(t, evaluated, expected)
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case *object.Error:
+			errObj, ok := evaluated.(*object.Error)
+			assert.True(t, ok)
+			assert.Equal(t, expected.Message, errObj.Message)
+		}
+	}
+}
+
+func TestModulesPerInterpreter(t *testing.T) {
+	dir := setupModules(t, map[string]string{
+		"counter.bel": `let count = 0; let bump = fn() { count = count + 1 };`,
+	})
+	defer os.RemoveAll(dir)
+
+	input := `let m = import("counter.bel"); m.bump(); m.count`
+	program := parser.New(lexer.NewWithFile(input, filepath.Join(dir, "main.bel"))).ParseProgram()
+
+	// Each environment is a separate interpreter with modules of its own
+	env := object.NewEnvironment()
+	testIntegerObject(t, Eval(program, env), 1)
+	testIntegerObject(t, Eval(program, env), 2)
+	testIntegerObject(t, Eval(program, object.NewEnvironment()), 1)
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := setupModules(t, map[string]string{
+		"cycle/a.bel": `let b = import("b.bel");`,
+		"cycle/b.bel": `let a = import("a.bel");`,
+		"broken.bel":  `let x 5;`,
+		"failing.bel": "let y = 2;\nlet x = 1 + true;",
+	})
+	defer os.RemoveAll(dir)
+	main := filepath.Join(dir, "main.bel")
+
+	errObj, ok := testEvalFile(main, `import("cycle/a.bel")`).(*object.Error)
+	assert.True(t, ok)
+	assert.Contains(t, errObj.Message, "import cycle detected: ")
+	assert.Contains(t, errObj.Message, filepath.Join("cycle", "a.bel")+" -> ")
+
+	errObj, ok = testEvalFile(main, `import("broken.bel")`).(*object.Error)
+	assert.True(t, ok)
+	assert.Contains(t, errObj.Message, "broken.bel:1:7: expected next token to be =, got INT")
+
+	errObj, ok = testEvalFile(main, `import("failing.bel")`).(*object.Error)
+	assert.True(t, ok)
+	assert.Equal(t, "type mismatch: INTEGER + BOOLEAN", errObj.Message)
+	assert.Equal(t, filepath.Join(dir, "failing.bel")+":2:11", errObj.Pos.String())
+
+	errObj, ok = testEvalFile(main, `import("missing.bel")`).(*object.Error)
+	assert.True(t, ok)
+	assert.Contains(t, errObj.Message, "could not import \"missing.bel\"")
+}
+
+func setupModules(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "bellamy")
+	assert.NoError(t, err)
+	for name, source := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		assert.NoError(t, ioutil.WriteFile(path, []byte(source), 0644))
+	}
+	return dir
+}
+
+func testEvalFile(file, input string) object.Object {
+	l := lexer.NewWithFile(input, file)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	return runProgram(program, Options{})
+}
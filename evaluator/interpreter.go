@@ -0,0 +1,48 @@
+package evaluator
+
+import "bellamy/object"
+
+// Options configure an interpreter
+type Options struct {
+	// CheckedArithmetic makes integer operations that overflow an int64 produce an
+	// error instead of silently wrapping around
+	CheckedArithmetic bool
+}
+
+// MaxCallDepth limits how deep calls can nest before the program is stopped. Each call
+// takes several Go stack frames, so the limit is well below the one of the vm, to stop
+// the program long before the Go stack runs out.
+const MaxCallDepth = 1 << 16
+
+// interpreter holds the state of a single run of the evaluator. It is kept as the
+// context of the environment a program is evaluated in, so that separate programs,
+// like those of two embedded interpreters, never see each other's options or modules.
+type interpreter struct {
+	operators *Operators
+	modules   *ModuleCache
+	// depth is the number of calls to functions in progress
+	depth int
+}
+
+func newInterpreter(options Options) *interpreter {
+	return &interpreter{operators: NewOperators(options, apply), modules: NewModuleCache()}
+}
+
+// NewEnvironment creates the top level environment of a new interpreter that runs
+// programs with options. Evaluating a program in an environment from
+// object.NewEnvironment runs it with the default Options.
+func NewEnvironment(options Options) *object.Environment {
+	env := object.NewEnvironment()
+	env.SetContext(newInterpreter(options))
+	return env
+}
+
+// interpreterOf returns the interpreter env belongs to, starting a new one when it has none yet
+func interpreterOf(env *object.Environment) *interpreter {
+	if in, ok := env.Context().(*interpreter); ok {
+		return in
+	}
+	in := newInterpreter(Options{})
+	env.SetContext(in)
+	return in
+}
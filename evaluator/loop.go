@@ -0,0 +1,85 @@
+package evaluator
+
+import (
+	"bellamy/ast"
+	"bellamy/object"
+)
+
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		cond := Eval(ws.Condition, env)
+		if isError(cond) {
+			return cond
+		}
+		if !object.IsTruthy(cond) {
+			return object.NULL
+		}
+		// Like for loops, each iteration gets its own scope so closures capture that iteration's variables
+		if result, done := evalLoopBody(ws.Body, object.NewEnclosedEnvironment(env)); done {
+			return result
+		}
+	}
+}
+
+func evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(fs.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	items, err := Iterate(iterable)
+	if err != nil {
+		err.Pos = fs.Iterable.Pos()
+		return err
+	}
+
+	for _, item := range items {
+		// Each iteration gets its own scope so closures capture that iteration's value
+		iterEnv := object.NewEnclosedEnvironment(env)
+		iterEnv.Set(fs.Variable.Value, item)
+		if result, done := evalLoopBody(fs.Body, iterEnv); done {
+			return result
+		}
+	}
+	return object.NULL
+}
+
+// Iterate lists the items a for loop visits in iterable: the elements of an array
+// or tuple, the keys of a hash or the characters of a string
+func Iterate(iterable object.Object) ([]object.Object, *object.Error) {
+	var items []object.Object
+	switch iterable := iterable.(type) {
+	case *object.Array:
+		items = iterable.Elements
+	case *object.Tuple:
+		items = iterable.Elements
+	case *object.Hash:
+		for _, pair := range iterable.Pairs() {
+			items = append(items, pair.Key)
+		}
+	case *object.String:
+		for _, ch := range iterable.Value {
+			items = append(items, &object.String{Value: string(ch)})
+		}
+	default:
+		return nil, object.NewError("cannot iterate over %s", iterable.Type())
+	}
+	return items, nil
+}
+
+// evalLoopBody runs a single iteration of a loop, reporting whether the loop is finished
+// along with the value the loop statement should produce
+func evalLoopBody(body *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	result := Eval(body, env)
+	if result == nil {
+		return nil, false
+	}
+	switch result.Type() {
+	case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+		return result, true
+	case object.BREAK_OBJ:
+		return object.NULL, true
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,45 @@
+package evaluator
+
+import "bellamy/object"
+
+// The operations below are shared with the vm, so that both engines agree on
+// every result and every error message. HashKey, Iterate, Slice and BindArguments
+// are shared the same way.
+
+// Operators applies the operators for a single program. The evaluator and the vm each
+// keep one for every program they run.
+type Operators struct {
+	options  Options
+	comparer *object.Comparer
+}
+
+// NewOperators creates the Operators of a program run with options, which calls the
+// equals functions of hashes through apply
+func NewOperators(options Options, apply object.Applier) *Operators {
+	return &Operators{options: options, comparer: object.NewComparer(apply)}
+}
+
+// Infix applies a binary operator such as + or ==
+func (ops *Operators) Infix(op string, left, right object.Object) object.Object {
+	return evalInfixExpression(op, left, right, ops)
+}
+
+// Prefix applies a unary operator, ! or -
+func (ops *Operators) Prefix(op string, right object.Object) object.Object {
+	return evalPrefixExpression(op, right, ops)
+}
+
+// Index looks up `left[index]`
+func Index(left, index object.Object) object.Object {
+	return evalIndexExpression(left, index)
+}
+
+// Member looks up `obj.name`
+func Member(obj object.Object, name string) object.Object {
+	return evalMemberExpression(obj, name)
+}
+
+// SetIndex stores val at `left[index]`
+func SetIndex(left, index, val object.Object) object.Object {
+	return evalIndexAssignment(left, index, val)
+}
@@ -0,0 +1,76 @@
+package evaluator
+
+import (
+	"bellamy/ast"
+	"bellamy/object"
+)
+
+func evalSliceExpression(left object.Object, node *ast.SliceExpression, env *object.Environment) object.Object {
+	start := evalSliceBound(node.Start, env)
+	if isError(start) {
+		return start
+	}
+	end := evalSliceBound(node.End, env)
+	if isError(end) {
+		return end
+	}
+	return Slice(left, start, end)
+}
+
+// evalSliceBound evaluates one end of a slice, which is nil when it was left out
+func evalSliceBound(exp ast.Expression, env *object.Environment) object.Object {
+	if exp == nil {
+		return nil
+	}
+	return Eval(exp, env)
+}
+
+// Slice takes the part of a string or array from start up to end. Either bound
+// may be nil, to slice from the beginning or up to the end.
+func Slice(left, start, end object.Object) object.Object {
+	// Strings are sliced by code point, so they are worked on as runes
+	var runes []rune
+	var length int64
+	switch left := left.(type) {
+	case *object.String:
+		runes = []rune(left.Value)
+		length = int64(len(runes))
+	case *object.Array:
+		length = int64(len(left.Elements))
+	default:
+		return object.NewError("slice operator not supported: %s", left.Type())
+	}
+
+	from, err := sliceBound(start, 0)
+	if err != nil {
+		return err
+	}
+	to, err := sliceBound(end, length)
+	if err != nil {
+		return err
+	}
+	if from < 0 || to > length || from > to {
+		return object.NewError("slice bounds out of range [%d:%d] with length %d", from, to, length)
+	}
+
+	switch left := left.(type) {
+	case *object.String:
+		return &object.String{Value: string(runes[from:to])}
+	default:
+		elements := make([]object.Object, to-from)
+		copy(elements, left.(*object.Array).Elements[from:to])
+		return &object.Array{Elements: elements}
+	}
+}
+
+// sliceBound reads one end of a slice, using def when it was left out
+func sliceBound(bound object.Object, def int64) (int64, *object.Error) {
+	if bound == nil {
+		return def, nil
+	}
+	i, ok := bound.(*object.Integer)
+	if !ok {
+		return 0, object.NewError("slice index must be INTEGER, got %s", bound.Type())
+	}
+	return i.Value, nil
+}
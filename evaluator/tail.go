@@ -0,0 +1,63 @@
+package evaluator
+
+import (
+	"bellamy/ast"
+	"bellamy/object"
+)
+
+// evalTail evaluates node in tail position, which is the value of a return statement
+// or the last expression of a function body, following it down through blocks and
+// if branches. A call found there is not made but returned as a tailCall.
+func evalTail(node ast.Node, env *object.Environment) object.Object {
+	var result object.Object
+	switch node := node.(type) {
+	case *ast.BlockStatement:
+		result = evalTailBlockStatement(node, env)
+	case *ast.ExpressionStatement:
+		result = evalTail(node.Expression, env)
+	case *ast.IfExpression:
+		result = evalTailIfExpression(node, env)
+	case *ast.CallExpression:
+		call, res, _ := prepareCall(node, env)
+		if call == nil {
+			result = res
+		} else {
+			result = call
+		}
+	default:
+		return Eval(node, env)
+	}
+	return withPosition(result, node)
+}
+
+// evalTailBlockStatement is evalBlockStatement with the last statement in tail position
+func evalTailBlockStatement(block *ast.BlockStatement, env *object.Environment) object.Object {
+	var result object.Object
+	for i, stmt := range block.Statements {
+		if i == len(block.Statements)-1 {
+			return evalTail(stmt, env)
+		}
+		result = Eval(stmt, env)
+		if result != nil {
+			switch result.Type() {
+			case object.RETURN_VALUE_OBJ, object.ERROR_OBJ, object.BREAK_OBJ, object.CONTINUE_OBJ:
+				return result
+			}
+		}
+	}
+	return result
+}
+
+// evalTailIfExpression is evalIfExpression with both branches in tail position
+func evalTailIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
+	cond := Eval(ie.Condition, env)
+	if isError(cond) {
+		return cond
+	}
+	if object.IsTruthy(cond) {
+		return evalTail(ie.Consequence, env)
+	} else if ie.Alternative != nil {
+		return evalTail(ie.Alternative, env)
+	}
+	return object.NULL
+}
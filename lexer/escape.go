@@ -0,0 +1,60 @@
+package lexer
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var escapes = map[byte]byte{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'0':  0,
+	'\\': '\\',
+	'"':  '"',
+	'$':  '$',
+}
+
+// Unescape replaces the escape sequences in the body of a double quoted string with
+// the characters they stand for. Along with the single character escapes, \u{...}
+// inserts the unicode code point with the given hex value.
+func Unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+
+	var out bytes.Buffer
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			out.WriteByte(s[i])
+			continue
+		}
+		i++
+		if i == len(s) {
+			return "", fmt.Errorf("unterminated escape sequence")
+		}
+		if ch, ok := escapes[s[i]]; ok {
+			out.WriteByte(ch)
+			continue
+		}
+		if s[i] != 'u' {
+			return "", fmt.Errorf("invalid escape sequence \\%c", s[i])
+		}
+
+		end := strings.IndexByte(s[i:], '}')
+		if !strings.HasPrefix(s[i:], "u{") || end == -1 {
+			return "", fmt.Errorf("invalid unicode escape, expected \\u{...}")
+		}
+		hex := s[i+2 : i+end]
+		code, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || !utf8.ValidRune(rune(code)) {
+			return "", fmt.Errorf("invalid unicode code point \\u{%s}", hex)
+		}
+		out.WriteRune(rune(code))
+		i += end
+	}
+	return out.String(), nil
+}
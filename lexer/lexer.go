@@ -3,18 +3,40 @@ package lexer
 import (
 	"bellamy/token"
 	"bellamy/utils"
+	"strings"
+	"unicode/utf8"
 )
 
 type Lexer struct {
 	input        string
-	position     int
-	readPosition int
-	ch           byte
+	file         string
+	position     int // byte offset of ch in input
+	readPosition int // byte offset of the rune after ch
+	ch           rune
+
+	// line and column of ch, both 1 based. Columns count runes, not bytes
+	line   int
+	column int
+
+	// KeepComments makes NextToken return COMMENT tokens rather than skipping
+	// over them, for tools that need to see the comments in the source
+	KeepComments bool
 }
 
 func New(input string) *Lexer {
-	l := &Lexer{input: input}
-	l.readChar() // read in first byte to initialize
+	return NewWithFile(input, "")
+}
+
+// NewWithFile creates a Lexer whose token positions are reported against file
+func NewWithFile(input, file string) *Lexer {
+	return NewAt(input, token.Position{File: file, Line: 1, Column: 1})
+}
+
+// NewAt creates a Lexer for a snippet of a larger source that begins at pos,
+// such as the expression inside a string interpolation
+func NewAt(input string, pos token.Position) *Lexer {
+	l := &Lexer{input: input, file: pos.File, line: pos.Line, column: pos.Column - 1}
+	l.readChar() // read in first rune to initialize
 	return l
 }
 
@@ -22,13 +44,23 @@ func (l *Lexer) NextToken() token.Token {
 	var t token.Token
 
 	l.skipWhitespace()
+	pos := l.pos()
+
+	if l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*') {
+		t = l.readComment()
+		t.Pos = pos
+		if t.Type == token.COMMENT && !l.KeepComments {
+			return l.NextToken()
+		}
+		return t
+	}
 
 	switch l.ch {
 	case '=':
 		if l.peekChar() == '=' {
 			ch := l.ch
 			l.readChar()
-			t = token.FromMultiChar(token.EQ, []byte{ch, l.ch})
+			t = token.FromMultiChar(token.EQ, []rune{ch, l.ch})
 		} else {
 			t = token.FromChar(token.ASSIGN, l.ch)
 		}
@@ -52,86 +84,238 @@ func (l *Lexer) NextToken() token.Token {
 		t = token.FromChar(token.RBRACKET, l.ch)
 
 	case '+':
-		t = token.FromChar(token.PLUS, l.ch)
+		t = l.readOperatorAssign(token.PLUS, token.PLUS_ASSIGN)
 	case '-':
-		t = token.FromChar(token.MINUS, l.ch)
+		t = l.readOperatorAssign(token.MINUS, token.MINUS_ASSIGN)
 	case '<':
-		t = token.FromChar(token.LT, l.ch)
+		t = l.readOperatorAssign(token.LT, token.LE)
 	case '>':
-		t = token.FromChar(token.GT, l.ch)
+		t = l.readOperatorAssign(token.GT, token.GE)
+	case '&':
+		t = l.readDoubleChar(token.AND)
+	case '|':
+		t = l.readDoubleChar(token.OR)
 	case '!':
 		if l.peekChar() == '=' {
 			ch := l.ch
 			l.readChar()
-			t = token.FromMultiChar(token.NE, []byte{ch, l.ch})
+			t = token.FromMultiChar(token.NE, []rune{ch, l.ch})
 		} else {
 			t = token.FromChar(token.BANG, l.ch)
 		}
 	case '*':
-		t = token.FromChar(token.ASTERISK, l.ch)
+		t = l.readOperatorAssign(token.ASTERISK, token.ASTERISK_ASSIGN)
 	case '/':
-		t = token.FromChar(token.SLASH, l.ch)
+		t = l.readOperatorAssign(token.SLASH, token.SLASH_ASSIGN)
+	case '%':
+		t = l.readOperatorAssign(token.PERCENT, token.PERCENT_ASSIGN)
 	case '"':
-		t.Type = token.STRING
-		t.Literal = l.readString()
+		t = l.readString()
+	case '`':
+		t = l.readRawString()
 	case '.':
-		t = token.FromChar(token.PERIOD, l.ch)
+		if strings.HasPrefix(l.input[l.position:], token.ELLIPSIS) {
+			l.readChar()
+			l.readChar()
+			t = token.Token{Type: token.ELLIPSIS, Literal: token.ELLIPSIS}
+		} else {
+			t = token.FromChar(token.PERIOD, l.ch)
+		}
+	case '?':
+		t = l.readQuestion()
 	case 0:
 		t = token.FromChar(token.EOF, '0')
 	default:
 		if utils.IsLetter(l.ch) {
 			t.Literal = l.readIdentifier()
 			t.Type = token.LookupIdent(t.Literal)
+			t.Pos = pos
 			return t
 		} else if utils.IsDigit(l.ch) {
-			t.Literal = l.readNumber()
-			t.Type = token.INT
+			t.Literal, t.Type = l.readNumber()
+			t.Pos = pos
 			return t
 		} else {
 			t = token.FromChar(token.ILLEGAL, l.ch)
 		}
 	}
 	l.readChar()
+	t.Pos = pos
 	return t
 }
 
-func (l *Lexer) peekChar() byte {
+// readOperatorAssign reads an operator that may be followed by = to form
+// a compound assignment like += or a comparison like <=
+func (l *Lexer) readOperatorAssign(op, opAssign token.TokenType) token.Token {
+	if l.peekChar() == '=' {
+		ch := l.ch
+		l.readChar()
+		return token.FromMultiChar(opAssign, []rune{ch, l.ch})
+	}
+	return token.FromChar(op, l.ch)
+}
+
+// readDoubleChar reads operators such as && that are made of the current character twice
+func (l *Lexer) readDoubleChar(tokenType token.TokenType) token.Token {
+	if l.peekChar() != l.ch {
+		return token.FromChar(token.ILLEGAL, l.ch)
+	}
+	ch := l.ch
+	l.readChar()
+	return token.FromMultiChar(tokenType, []rune{ch, l.ch})
+}
+
+// readQuestion reads the operators starting with ?, which are ?? and the optional ?. and ?[
+func (l *Lexer) readQuestion() token.Token {
+	var tokenType token.TokenType
+	switch l.peekChar() {
+	case '?':
+		tokenType = token.NULLISH
+	case '.':
+		tokenType = token.OPTIONAL_PERIOD
+	case '[':
+		tokenType = token.OPTIONAL_LBRACKET
+	default:
+		return token.FromChar(token.ILLEGAL, l.ch)
+	}
+	ch := l.ch
+	l.readChar()
+	return token.FromMultiChar(tokenType, []rune{ch, l.ch})
+}
+
+func (l *Lexer) pos() token.Position {
+	return token.Position{File: l.file, Line: l.line, Column: l.column}
+}
+
+func (l *Lexer) peekChar() rune {
 	if l.readPosition >= len(l.input) {
 		return 0
 	}
-	return l.input[l.readPosition]
+	ch, _ := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	return ch
 }
 
+// readChar advances to the next rune of the input. Invalid UTF-8 is read a byte
+// at a time as utf8.RuneError, which the lexer reports as ILLEGAL.
 func (l *Lexer) readChar() {
-	l.ch = l.peekChar()
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
 	l.position = l.readPosition
-	l.readPosition += 1
+	if l.readPosition >= len(l.input) {
+		l.ch = 0
+	} else {
+		var width int
+		l.ch, width = utf8.DecodeRuneInString(l.input[l.readPosition:])
+		l.readPosition += width
+	}
+	l.column++
 }
 
-func (l *Lexer) readString() string {
-	b := []byte{}
+// readString reads a double quoted string, processing any escape sequences in it.
+// Strings containing ${...} interpolations are returned as a TEMPLATE token holding
+// the raw source between the quotes, for the parser to split up.
+// It leaves the lexer on the closing quote.
+func (l *Lexer) readString() token.Token {
 	l.readChar()
-	for l.ch != '"' && l.ch != '0' {
-		b = append(b, l.ch)
+	position := l.position
+	end, interpolated := stringEnd(l.input, position)
+	if end == -1 {
+		for l.ch != 0 {
+			l.readChar()
+		}
+		return token.Token{Type: token.ERROR, Literal: "unterminated string"}
+	}
+	for l.position < end {
+		l.readChar()
+	}
+
+	raw := l.input[position:end]
+	if interpolated {
+		return token.Token{Type: token.TEMPLATE, Literal: raw}
+	}
+	value, err := Unescape(raw)
+	if err != nil {
+		return token.Token{Type: token.ERROR, Literal: err.Error()}
+	}
+	return token.Token{Type: token.STRING, Literal: value}
+}
+
+// readRawString reads a backtick quoted string, which may span multiple lines and
+// has no escape sequences. It leaves the lexer on the closing backtick.
+func (l *Lexer) readRawString() token.Token {
+	l.readChar()
+	position := l.position
+	for l.ch != '`' {
+		if l.ch == 0 {
+			return token.Token{Type: token.ERROR, Literal: "unterminated raw string"}
+		}
+		l.readChar()
+	}
+	return token.Token{Type: token.STRING, Literal: l.input[position:l.position]}
+}
+
+// readComment reads a // line comment or a /* block comment */, which may be nested.
+// It leaves the lexer on the first character after the comment.
+func (l *Lexer) readComment() token.Token {
+	position := l.position
+	if l.peekChar() == '/' {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+		return token.Token{Type: token.COMMENT, Literal: l.input[position:l.position]}
+	}
+
+	depth := 0
+	for {
+		switch {
+		case l.ch == 0:
+			return token.Token{Type: token.ERROR, Literal: "unterminated block comment"}
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
 		l.readChar()
+		if depth == 0 {
+			return token.Token{Type: token.COMMENT, Literal: l.input[position:l.position]}
+		}
 	}
-	return string(b)
 }
 
+// readIdentifier reads a letter followed by any number of letters and digits
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for utils.IsLetter(l.ch) {
+	for utils.IsLetter(l.ch) || utils.IsDigit(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-func (l *Lexer) readNumber() string {
+// readNumber reads an integer or, when the digits are followed by a decimal point
+// and more digits, a float. `5.abs()` is still an integer followed by a method call.
+// Any letters, digits and _ running on from the number are taken as part of it, so
+// prefixed literals like 0xFF and separators like 1_000 are read whole, and malformed
+// ones like 0b102 are reported by the parser rather than split into several tokens.
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	position := l.position
-	for utils.IsDigit(l.ch) {
+	tokenType := token.TokenType(token.INT)
+	l.readDigits()
+	if l.ch == '.' && utils.IsDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+	return l.input[position:l.position], tokenType
+}
+
+func (l *Lexer) readDigits() {
+	for utils.IsDigit(l.ch) || utils.IsLetter(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
 }
 
 func (l *Lexer) skipWhitespace() {
@@ -12,6 +12,7 @@ func TestNextToken(t *testing.T) {
 	input := `
 	let five = 5;
 	//!<>*
+	!<>*/
 	let ten = 10;
 	let add = fn(x, y) {
 	  x + y;
@@ -45,12 +46,11 @@ func TestNextToken(t *testing.T) {
 		{token.INT, "5"},
 		{token.SEMICOLON, ";"},
 
-		{token.SLASH, "/"},
-		{token.SLASH, "/"},
 		{token.BANG, "!"},
 		{token.LT, "<"},
 		{token.GT, ">"},
 		{token.ASTERISK, "*"},
+		{token.SLASH, "/"},
 
 		{token.LET, "let"},
 		{token.IDENT, "ten"},
@@ -158,3 +158,309 @@ func TestNextToken(t *testing.T) {
 	}
 
 }
+
+func TestTokenPositions(t *testing.T) {
+	input := `let five = 5;
+let ten =
+    10;`
+
+	tests := []struct {
+		expectedLiteral string
+		expectedLine    int
+		expectedColumn  int
+	}{
+		{"let", 1, 1},
+		{"five", 1, 5},
+		{"=", 1, 10},
+		{"5", 1, 12},
+		{";", 1, 13},
+		{"let", 2, 1},
+		{"ten", 2, 5},
+		{"=", 2, 9},
+		{"10", 3, 5},
+		{";", 3, 7},
+	}
+
+	l := NewWithFile(input, "test.bel")
+	for _, tt := range tests {
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedLiteral, tok.Literal)
+		assert.Equal(t, token.Position{File: "test.bel", Line: tt.expectedLine, Column: tt.expectedColumn}, tok.Pos)
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := `let café = "日本語"; naïve + π
+¿`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.LET, "let", 1},
+		{token.IDENT, "café", 5},
+		{token.ASSIGN, "=", 10},
+		{token.STRING, "日本語", 12},
+		{token.SEMICOLON, ";", 17},
+		{token.IDENT, "naïve", 19},
+		{token.PLUS, "+", 25},
+		{token.IDENT, "π", 27},
+		{token.ILLEGAL, "¿", 1},
+		{token.EOF, "0", 2},
+	}
+
+	l := New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type, tt.expectedLiteral)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal)
+		assert.Equal(t, tt.expectedColumn, tok.Pos.Column, tt.expectedLiteral)
+	}
+}
+
+func TestAssignmentOperators(t *testing.T) {
+	input := `x = 1; x += 2; x -= 3; x *= 4; x /= 5; x + 1`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "x"}, {token.ASSIGN, "="}, {token.INT, "1"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.PLUS_ASSIGN, "+="}, {token.INT, "2"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.MINUS_ASSIGN, "-="}, {token.INT, "3"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.ASTERISK_ASSIGN, "*="}, {token.INT, "4"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.SLASH_ASSIGN, "/="}, {token.INT, "5"}, {token.SEMICOLON, ";"},
+		{token.IDENT, "x"}, {token.PLUS, "+"}, {token.INT, "1"},
+		{token.EOF, "0"},
+	}
+
+	l := New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal)
+	}
+}
+
+func TestLogicalAndComparisonOperators(t *testing.T) {
+	input := `a && b || c <= d >= e < f & g`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"}, {token.AND, "&&"}, {token.IDENT, "b"}, {token.OR, "||"},
+		{token.IDENT, "c"}, {token.LE, "<="}, {token.IDENT, "d"}, {token.GE, ">="},
+		{token.IDENT, "e"}, {token.LT, "<"}, {token.IDENT, "f"}, {token.ILLEGAL, "&"},
+		{token.IDENT, "g"},
+		{token.EOF, "0"},
+	}
+
+	l := New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal)
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	input := `3.14 10 0.5 5.abs() 1.`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "3.14"},
+		{token.INT, "10"},
+		{token.FLOAT, "0.5"},
+		{token.INT, "5"}, {token.PERIOD, "."}, {token.IDENT, "abs"}, {token.LPAREN, "("}, {token.RPAREN, ")"},
+		{token.INT, "1"}, {token.PERIOD, "."},
+		{token.EOF, "0"},
+	}
+
+	l := New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal)
+	}
+}
+
+func TestIdentifiersAndNumberForms(t *testing.T) {
+	input := `x1 _tmp2 0xFF 0o17 0b1010 1_000_000 1_000.5 0b102 5x`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.IDENT, "x1"},
+		{token.IDENT, "_tmp2"},
+		{token.INT, "0xFF"},
+		{token.INT, "0o17"},
+		{token.INT, "0b1010"},
+		{token.INT, "1_000_000"},
+		{token.FLOAT, "1_000.5"},
+		{token.INT, "0b102"},
+		{token.INT, "5x"},
+		{token.EOF, "0"},
+	}
+
+	l := New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal)
+	}
+}
+
+func TestNullishOperators(t *testing.T) {
+	input := `null a ?? b a?.b a?[0] a ? b`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.NULL, "null"},
+		{token.IDENT, "a"}, {token.NULLISH, "??"}, {token.IDENT, "b"},
+		{token.IDENT, "a"}, {token.OPTIONAL_PERIOD, "?."}, {token.IDENT, "b"},
+		{token.IDENT, "a"}, {token.OPTIONAL_LBRACKET, "?["}, {token.INT, "0"}, {token.RBRACKET, "]"},
+		{token.IDENT, "a"}, {token.ILLEGAL, "?"}, {token.IDENT, "b"},
+		{token.EOF, "0"},
+	}
+
+	l := New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal)
+	}
+}
+
+func TestEllipsis(t *testing.T) {
+	input := `fn(...rest) a.b ..`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FUNCTION, "fn"}, {token.LPAREN, "("}, {token.ELLIPSIS, "..."}, {token.IDENT, "rest"}, {token.RPAREN, ")"},
+		{token.IDENT, "a"}, {token.PERIOD, "."}, {token.IDENT, "b"},
+		{token.PERIOD, "."}, {token.PERIOD, "."},
+		{token.EOF, "0"},
+	}
+
+	l := New(input)
+	for _, tt := range tests {
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal)
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading comment
+let x = 1; // trailing
+/* block
+   comment */ x /* inline */ / 2;
+/* outer /* nested */ still comment */ x`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.COMMENT, "// leading comment"},
+		{token.LET, "let"}, {token.IDENT, "x"}, {token.ASSIGN, "="}, {token.INT, "1"}, {token.SEMICOLON, ";"},
+		{token.COMMENT, "// trailing"},
+		{token.COMMENT, "/* block\n   comment */"},
+		{token.IDENT, "x"},
+		{token.COMMENT, "/* inline */"},
+		{token.SLASH, "/"}, {token.INT, "2"}, {token.SEMICOLON, ";"},
+		{token.COMMENT, "/* outer /* nested */ still comment */"},
+		{token.IDENT, "x"},
+		{token.EOF, "0"},
+	}
+
+	l := New(input)
+	l.KeepComments = true
+	for _, tt := range tests {
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal)
+	}
+
+	// Without KeepComments the comments are skipped entirely
+	l = New(input)
+	for _, tt := range tests {
+		if tt.expectedType == token.COMMENT {
+			continue
+		}
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal)
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	l := New("x /* never /* closed */")
+	assert.Equal(t, token.TokenType(token.IDENT), l.NextToken().Type)
+	tok := l.NextToken()
+	assert.Equal(t, token.TokenType(token.ERROR), tok.Type)
+	assert.Equal(t, "unterminated block comment", tok.Literal)
+	assert.Equal(t, token.Position{Line: 1, Column: 3}, tok.Pos)
+	assert.Equal(t, token.TokenType(token.EOF), l.NextToken().Type)
+}
+
+func TestStrings(t *testing.T) {
+	tests := []struct {
+		input           string
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{`"route 101"`, token.STRING, "route 101"},
+		{`"tab\there"`, token.STRING, "tab\there"},
+		{`"line\nbreak\r"`, token.STRING, "line\nbreak\r"},
+		{`"say \"hi\""`, token.STRING, `say "hi"`},
+		{`"back\\slash"`, token.STRING, `back\slash`},
+		{`"snow \u{2603} \u{1F600}"`, token.STRING, "snow \u2603 \U0001F600"},
+		{"`raw \\n ${x} \"quoted\"\nsecond line`", token.STRING, "raw \\n ${x} \"quoted\"\nsecond line"},
+		{`""`, token.STRING, ""},
+		{`"never closed`, token.ERROR, "unterminated string"},
+		{`"ends in escape\"`, token.ERROR, "unterminated string"},
+		{"`never closed", token.ERROR, "unterminated raw string"},
+		{`"bad \q escape"`, token.ERROR, "invalid escape sequence \\q"},
+		{`"bad \u{zz}"`, token.ERROR, "invalid unicode code point \\u{zz}"},
+		{`"bad \u{110000}"`, token.ERROR, "invalid unicode code point \\u{110000}"},
+		{`"bad \u00e9"`, token.ERROR, "invalid unicode escape, expected \\u{...}"},
+		{`"total: ${count * 2}"`, token.TEMPLATE, "total: ${count * 2}"},
+		{`"${h["key"]} and ${ {"a": 1}["a"] }"`, token.TEMPLATE, `${h["key"]} and ${ {"a": 1}["a"] }`},
+		{`"outer ${"inner ${x}"}"`, token.TEMPLATE, `outer ${"inner ${x}"}`},
+		{`"not \${interpolated}"`, token.STRING, "not ${interpolated}"},
+		{`"cost: $5 {x}"`, token.STRING, "cost: $5 {x}"},
+		{`"open ${x"`, token.ERROR, "unterminated string"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		assert.Equal(t, tt.expectedType, tok.Type, tt.input)
+		assert.Equal(t, tt.expectedLiteral, tok.Literal, tt.input)
+		assert.Equal(t, token.Position{Line: 1, Column: 1}, tok.Pos)
+		assert.Equal(t, token.TokenType(token.EOF), l.NextToken().Type)
+	}
+}
+
+func TestSplitTemplate(t *testing.T) {
+	parts, err := SplitTemplate(`a\t${x + 1}${ "}" }\${z}`)
+	assert.NoError(t, err)
+	assert.Equal(t, []TemplatePart{
+		{Text: "a\t", Offset: 0},
+		{Text: "x + 1", IsExpression: true, Offset: 5},
+		{Text: ` "}" `, IsExpression: true, Offset: 13},
+		{Text: "${z}", Offset: 19},
+	}, parts)
+
+	_, err = SplitTemplate(`${x`)
+	assert.EqualError(t, err, "unterminated interpolation")
+}
@@ -0,0 +1,108 @@
+package lexer
+
+import "fmt"
+
+// TemplatePart is a piece of an interpolated string. Text is either literal text,
+// with its escapes already processed, or the source of an embedded expression.
+type TemplatePart struct {
+	Text         string
+	IsExpression bool
+	// Offset is where Text starts within the template source
+	Offset int
+}
+
+// SplitTemplate breaks the source of a TEMPLATE token up into its literal text and
+// the expressions embedded in it with ${...}
+func SplitTemplate(raw string) ([]TemplatePart, error) {
+	parts := []TemplatePart{}
+	start := 0
+	addText := func(end int) error {
+		if end == start {
+			return nil
+		}
+		text, err := Unescape(raw[start:end])
+		if err != nil {
+			return err
+		}
+		parts = append(parts, TemplatePart{Text: text, Offset: start})
+		return nil
+	}
+
+	for i := 0; i < len(raw); i++ {
+		switch {
+		case raw[i] == '\\':
+			i++
+		case raw[i] == '$' && i+1 < len(raw) && raw[i+1] == '{':
+			if err := addText(i); err != nil {
+				return nil, err
+			}
+			end := interpolationEnd(raw, i+2)
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated interpolation")
+			}
+			parts = append(parts, TemplatePart{Text: raw[i+2 : end], IsExpression: true, Offset: i + 2})
+			i = end
+			start = end + 1
+		}
+	}
+	if err := addText(len(raw)); err != nil {
+		return nil, err
+	}
+	return parts, nil
+}
+
+// stringEnd finds the closing quote of the double quoted string whose body starts at
+// index i of s, reporting whether it contains interpolations. It returns -1 if the
+// string is never closed.
+func stringEnd(s string, i int) (int, bool) {
+	interpolated := false
+	for ; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case s[i] == '"':
+			return i, interpolated
+		case s[i] == '$' && i+1 < len(s) && s[i+1] == '{':
+			end := interpolationEnd(s, i+2)
+			if end == -1 {
+				return -1, false
+			}
+			interpolated = true
+			i = end
+		}
+	}
+	return -1, false
+}
+
+// interpolationEnd finds the } closing the interpolated expression starting at index
+// i of s, skipping over any braces and strings nested in the expression
+func interpolationEnd(s string, i int) int {
+	depth := 0
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			end, _ := stringEnd(s, i+1)
+			if end == -1 {
+				return -1
+			}
+			i = end
+		case '`':
+			end := i + 1
+			for end < len(s) && s[end] != '`' {
+				end++
+			}
+			if end == len(s) {
+				return -1
+			}
+			i = end
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
@@ -2,21 +2,42 @@ package main
 
 import (
 	"bellamy/repl"
+	"flag"
+	"fmt"
 	"os"
 )
 
 func main() {
-	if len(os.Args) == 1 {
-		repl.StartEvalRepl(os.Stdin, os.Stdout)
-	} else {
-		arg := os.Args[1]
-		switch arg[0] {
-		case 'p':
-			repl.StartParseRepl(os.Stdin, os.Stdout)
-		case 'l':
-			repl.StartLexRepl(os.Stdin, os.Stdout)
-		default:
-			repl.StartEvalRepl(os.Stdin, os.Stdout)
+	var config repl.Config
+	flag.BoolVar(&config.Options.CheckedArithmetic, "checked", false, "report integer overflow as an error instead of wrapping")
+	flag.StringVar(&config.Engine, "engine", "eval", "run programs with the tree walking evaluator (eval) or the bytecode vm (vm)")
+	flag.Parse()
+
+	if config.Engine != "eval" && config.Engine != "vm" {
+		fmt.Fprintf(os.Stderr, "unknown engine %q, expected eval or vm\n", config.Engine)
+		os.Exit(2)
+	}
+
+	if flag.NArg() == 0 {
+		repl.StartRepl(os.Stdin, os.Stdout, config)
+		return
+	}
+
+	arg := flag.Arg(0)
+	if arg == "run" {
+		if flag.NArg() < 2 {
+			fmt.Fprintln(os.Stderr, "usage: bellamy [flags] run <file> [args...]")
+			os.Exit(2)
 		}
+		os.Exit(repl.RunFile(flag.Arg(1), flag.Args()[2:], config, os.Stderr))
+	}
+
+	switch arg[0] {
+	case 'p':
+		repl.StartParseRepl(os.Stdin, os.Stdout)
+	case 'l':
+		repl.StartLexRepl(os.Stdin, os.Stdout)
+	default:
+		repl.StartRepl(os.Stdin, os.Stdout, config)
 	}
 }
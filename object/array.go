@@ -16,10 +16,14 @@ func (_ *Array) Type() ObjectType {
 }
 
 func (a *Array) Inspect() string {
+	return inspect(a, map[Object]bool{})
+}
+
+func (a *Array) inspect(seen map[Object]bool) string {
 	var out bytes.Buffer
 	elements := []string{}
 	for _, e := range a.Elements {
-		elements = append(elements, e.Inspect())
+		elements = append(elements, inspect(e, seen))
 	}
 
 	out.WriteString("[")
@@ -2,7 +2,11 @@ package object
 
 const BUILTIN_OBJ = "BUILTIN"
 
-type BuiltinFunction func(args ...Object) Object
+// Applier calls fn with args. It is handed to builtins by the interpreter
+// so that they can call back into Bellamy functions they are given.
+type Applier func(fn Object, args ...Object) Object
+
+type BuiltinFunction func(apply Applier, args ...Object) Object
 
 type Builtin struct {
 	Fn BuiltinFunction
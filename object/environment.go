@@ -8,12 +8,31 @@ func NewEnvironment() *Environment {
 func NewEnclosedEnvironment(outer *Environment) *Environment {
 	env := NewEnvironment()
 	env.outer = outer
+	env.context = outer.context
 	return env
 }
 
 type Environment struct {
 	store map[string]Object
 	outer *Environment
+	// context is kept by the engine running the program for the whole of it,
+	// and is shared by every environment enclosed by the one it is set on
+	context interface{}
+}
+
+// Context returns what SetContext was given on this environment or the nearest
+// enclosing one that has it, or nil when there is none
+func (e *Environment) Context() interface{} {
+	for ; e != nil; e = e.outer {
+		if e.context != nil {
+			return e.context
+		}
+	}
+	return nil
+}
+
+func (e *Environment) SetContext(context interface{}) {
+	e.context = context
 }
 
 func (e *Environment) Get(name string) (Object, bool) {
@@ -28,3 +47,16 @@ func (e *Environment) Set(name string, value Object) Object {
 	e.store[name] = value
 	return value
 }
+
+// Assign updates an existing binding in whichever enclosing environment defines it,
+// reporting false when name has not been defined anywhere
+func (e *Environment) Assign(name string, value Object) (Object, bool) {
+	if _, ok := e.store[name]; ok {
+		e.store[name] = value
+		return value, true
+	}
+	if e.outer != nil {
+		return e.outer.Assign(name, value)
+	}
+	return nil, false
+}
@@ -0,0 +1,178 @@
+package object
+
+// EQUALS_KEY is the key of the function a hash can hold to decide for itself whether
+// it is equal to another value. It is called with the hash and the other value.
+const EQUALS_KEY = "equals"
+
+// Equaler is implemented by object types that decide for themselves whether they
+// are equal to another object, rather than being compared structurally
+type Equaler interface {
+	Equals(other Object) bool
+}
+
+// Equal reports whether a and b are deeply equal. Numbers compare by value, with an
+// INTEGER equal to a FLOAT of the same value, strings by content, and arrays and hashes
+// element by element. A hash holding a function under EQUALS_KEY is compared by
+// calling it through apply instead, and an error it returns is passed back.
+//
+// Arrays and hashes may contain themselves, so a pair of values that is already being
+// compared further up is taken to be equal rather than being compared again.
+func Equal(a, b Object, apply Applier) (bool, *Error) {
+	return NewComparer(apply).Equal(a, b)
+}
+
+// Comparer compares values the same way as Equal, calling equals functions through
+// apply. Comparing a pair of values again from inside the equals function called for
+// it falls back to structural equality, so `equals: fn(a, b) { a == b }` does not
+// recurse forever. That comparison is a separate call to Equal made by the program,
+// so an engine keeps a single Comparer for each program it runs. A Comparer is not
+// safe for concurrent use.
+type Comparer struct {
+	apply Applier
+	// hooksRunning holds the pairs of values whose equals function is being called
+	hooksRunning map[[2]Object]bool
+}
+
+func NewComparer(apply Applier) *Comparer {
+	return &Comparer{apply: apply, hooksRunning: map[[2]Object]bool{}}
+}
+
+// Equal reports whether a and b are deeply equal, see the package function Equal
+func (cm *Comparer) Equal(a, b Object) (bool, *Error) {
+	c := &comparison{Comparer: cm, seen: map[[2]Object]bool{}}
+	return c.equal(a, b)
+}
+
+// comparison is a single call to Equal
+type comparison struct {
+	*Comparer
+	seen map[[2]Object]bool
+}
+
+func (c *comparison) equal(a, b Object) (bool, *Error) {
+	if a == b {
+		return true, nil
+	}
+	if equal, ok, err := c.callHook(a, b); ok {
+		return equal, err
+	}
+	if equal, ok, err := c.callHook(b, a); ok {
+		return equal, err
+	}
+	if e, ok := a.(Equaler); ok {
+		return e.Equals(b), nil
+	}
+	if e, ok := b.(Equaler); ok {
+		return e.Equals(a), nil
+	}
+
+	switch a := a.(type) {
+	case *Integer:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == b.Value, nil
+		case *Float:
+			return float64(a.Value) == b.Value, nil
+		}
+	case *Float:
+		switch b := b.(type) {
+		case *Integer:
+			return a.Value == float64(b.Value), nil
+		case *Float:
+			return a.Value == b.Value, nil
+		}
+	case *String:
+		if b, ok := b.(*String); ok {
+			return a.Value == b.Value, nil
+		}
+	case *Boolean:
+		if b, ok := b.(*Boolean); ok {
+			return a.Value == b.Value, nil
+		}
+	case *Array:
+		if b, ok := b.(*Array); ok {
+			return c.equalArrays(a, b)
+		}
+	case *Hash:
+		if b, ok := b.(*Hash); ok {
+			return c.equalHashes(a, b)
+		}
+	case *Tuple:
+		if b, ok := b.(*Tuple); ok {
+			return c.equalElements(a.Elements, b.Elements)
+		}
+	}
+	return false, nil
+}
+
+func (c *comparison) equalArrays(a, b *Array) (bool, *Error) {
+	pair := [2]Object{a, b}
+	if c.seen[pair] {
+		return true, nil
+	}
+	c.seen[pair] = true
+	return c.equalElements(a.Elements, b.Elements)
+}
+
+func (c *comparison) equalElements(a, b []Object) (bool, *Error) {
+	if len(a) != len(b) {
+		return false, nil
+	}
+	for i := range a {
+		if equal, err := c.equal(a[i], b[i]); !equal || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (c *comparison) equalHashes(a, b *Hash) (bool, *Error) {
+	if a.Len() != b.Len() {
+		return false, nil
+	}
+	pair := [2]Object{a, b}
+	if c.seen[pair] {
+		return true, nil
+	}
+	c.seen[pair] = true
+
+	for _, aPair := range a.Pairs() {
+		bValue, ok := b.Get(aPair.Key.(Hashable))
+		if !ok {
+			return false, nil
+		}
+		if equal, err := c.equal(aPair.Value, bValue); !equal || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// callHook compares a and b with the equals function held by a, if a is a hash that has
+// one. ok reports whether there was a function to call.
+func (c *comparison) callHook(a, b Object) (equal, ok bool, err *Error) {
+	hash, isHash := a.(*Hash)
+	if !isHash || c.apply == nil {
+		return false, false, nil
+	}
+	hook, hasHook := hash.Get(&String{Value: EQUALS_KEY})
+	if !hasHook {
+		return false, false, nil
+	}
+	if hook.Type() != FUNCTION_OBJ && hook.Type() != BUILTIN_OBJ {
+		return false, false, nil
+	}
+
+	pair := [2]Object{a, b}
+	if c.hooksRunning[pair] {
+		return false, false, nil
+	}
+	c.hooksRunning[pair] = true
+	defer delete(c.hooksRunning, pair)
+
+	result := c.apply(hook, a, b)
+	if err, isErr := result.(*Error); isErr {
+		return false, true, err
+	}
+	return IsTruthy(result), true, nil
+}
@@ -0,0 +1,59 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// point compares equal to anything with the same x, to exercise Equaler
+type point struct {
+	x int64
+}
+
+func (p *point) Type() ObjectType { return "POINT" }
+func (p *point) Inspect() string  { return "point" }
+
+func (p *point) Equals(other Object) bool {
+	o, ok := other.(*point)
+	return ok && o.x == p.x
+}
+
+func TestEqualCycles(t *testing.T) {
+	a := &Array{Elements: []Object{&Integer{Value: 1}}}
+	a.Elements = append(a.Elements, a)
+	b := &Array{Elements: []Object{&Integer{Value: 1}}}
+	b.Elements = append(b.Elements, b)
+	c := &Array{Elements: []Object{&Integer{Value: 2}}}
+	c.Elements = append(c.Elements, c)
+
+	equal, err := Equal(a, b, nil)
+	assert.Nil(t, err)
+	assert.True(t, equal)
+
+	equal, err = Equal(a, c, nil)
+	assert.Nil(t, err)
+	assert.False(t, equal)
+
+	h1 := NewHash()
+	h1.Set(&String{Value: "self"}, h1)
+	h2 := NewHash()
+	h2.Set(&String{Value: "self"}, h2)
+
+	equal, err = Equal(h1, h2, nil)
+	assert.Nil(t, err)
+	assert.True(t, equal)
+}
+
+func TestEqualer(t *testing.T) {
+	a := &Array{Elements: []Object{&point{x: 1}}}
+	b := &Array{Elements: []Object{&point{x: 1}}}
+	c := &Array{Elements: []Object{&point{x: 2}}}
+
+	equal, _ := Equal(a, b, nil)
+	assert.True(t, equal)
+	equal, _ = Equal(a, c, nil)
+	assert.False(t, equal)
+	equal, _ = Equal(&Integer{Value: 1}, &point{x: 1}, nil)
+	assert.False(t, equal)
+}
@@ -1,11 +1,16 @@
 package object
 
-import "fmt"
+import (
+	"bellamy/token"
+	"fmt"
+)
 
 const ERROR_OBJ = "ERROR"
 
 type Error struct {
 	Message string
+	// Pos is where in the source the error was raised, if known
+	Pos token.Position
 }
 
 func (e *Error) Type() ObjectType {
@@ -13,9 +18,12 @@ func (e *Error) Type() ObjectType {
 }
 
 func (e *Error) Inspect() string {
+	if e.Pos.IsValid() {
+		return "ERROR: " + e.Pos.String() + ": " + e.Message
+	}
 	return "ERROR: " + e.Message
 }
 
 func NewError(format string, a ...interface{}) *Error {
 	return &Error{Message: fmt.Sprintf(format, a...)}
-}
\ No newline at end of file
+}
@@ -0,0 +1,43 @@
+package object
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+const FLOAT_OBJ = "FLOAT"
+
+type Float struct {
+	Value float64
+}
+
+// Inspect always shows a decimal point for whole numbers, so 2.0 is not mistaken for an INTEGER
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+	return s
+}
+
+func (f *Float) Type() ObjectType {
+	return FLOAT_OBJ
+}
+
+func (f *Float) HashKey() HashKey {
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
+// IsNumber reports whether o is an INTEGER or a FLOAT
+func IsNumber(o Object) bool {
+	return o.Type() == INTEGER_OBJ || o.Type() == FLOAT_OBJ
+}
+
+// NumberValue returns the value of an INTEGER or FLOAT as a float64
+func NumberValue(o Object) float64 {
+	if i, ok := o.(*Integer); ok {
+		return float64(i.Value)
+	}
+	return o.(*Float).Value
+}
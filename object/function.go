@@ -9,9 +9,19 @@ import (
 const FUNCTION_OBJ = "FUNCTION"
 
 type Function struct {
-	Parameters []*ast.Identifier
+	Parameters []*ast.Parameter
 	Body       *ast.BlockStatement
 	Env        *Environment
+	// Name is empty for anonymous functions
+	Name string
+}
+
+// Describe names the function for error messages
+func (f *Function) Describe() string {
+	if f.Name == "" {
+		return "anonymous function"
+	}
+	return "function `" + f.Name + "`"
 }
 
 func (f *Function) Type() ObjectType {
@@ -13,7 +13,10 @@ type HashKey struct {
 	Value uint64
 }
 
+// Hashable is implemented by the values that can be used as hash keys. Values with
+// equal contents must have the same HashKey, but different values may share one.
 type Hashable interface {
+	Object
 	HashKey() HashKey
 }
 
@@ -22,8 +25,84 @@ type HashPair struct {
 	Value Object
 }
 
+// Hash keeps its pairs in the order their keys were first inserted, which is the
+// order they are iterated and printed in
 type Hash struct {
-	Pairs map[HashKey]HashPair
+	pairs []HashPair
+	// index holds the positions in pairs of the keys sharing each HashKey. Keys rarely
+	// collide, but when they do each keeps its own pair and they are told apart by
+	// comparing the keys.
+	index map[HashKey][]int
+}
+
+func NewHash() *Hash {
+	return &Hash{index: map[HashKey][]int{}}
+}
+
+// find returns the position of key in pairs, or -1 if it is not in the hash
+func (h *Hash) find(key Hashable) int {
+	for _, i := range h.index[key.HashKey()] {
+		if keysEqual(h.pairs[i].Key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the value stored under key
+func (h *Hash) Get(key Hashable) (Object, bool) {
+	if i := h.find(key); i != -1 {
+		return h.pairs[i].Value, true
+	}
+	return nil, false
+}
+
+// Set stores value under key. Replacing the value of a key already in the hash
+// leaves it at its original position.
+func (h *Hash) Set(key Hashable, value Object) {
+	if i := h.find(key); i != -1 {
+		h.pairs[i].Value = value
+		return
+	}
+	hashed := key.HashKey()
+	h.index[hashed] = append(h.index[hashed], len(h.pairs))
+	h.pairs = append(h.pairs, HashPair{Key: key, Value: value})
+}
+
+// Delete removes key from the hash, reporting whether it was there
+func (h *Hash) Delete(key Hashable) bool {
+	i := h.find(key)
+	if i == -1 {
+		return false
+	}
+	// Build a new slice rather than shifting in place, as Pairs may have handed the
+	// old one out
+	h.pairs = append(h.pairs[:i:i], h.pairs[i+1:]...)
+	h.index = map[HashKey][]int{}
+	for j, pair := range h.pairs {
+		hashed := pair.Key.(Hashable).HashKey()
+		h.index[hashed] = append(h.index[hashed], j)
+	}
+	return true
+}
+
+// Copy returns a new hash holding the same pairs in the same order
+func (h *Hash) Copy() *Hash {
+	c := NewHash()
+	for _, pair := range h.pairs {
+		c.Set(pair.Key.(Hashable), pair.Value)
+	}
+	return c
+}
+
+func (h *Hash) Len() int {
+	return len(h.pairs)
+}
+
+// Pairs returns every key and value in the hash in insertion order. The slice is
+// shared with the hash and must not be modified.
+func (h *Hash) Pairs() []HashPair {
+	return h.pairs
 }
 
 func (h *Hash) Type() ObjectType {
@@ -31,10 +110,14 @@ func (h *Hash) Type() ObjectType {
 }
 
 func (h *Hash) Inspect() string {
+	return inspect(h, map[Object]bool{})
+}
+
+func (h *Hash) inspect(seen map[Object]bool) string {
 	var out bytes.Buffer
 	pairs := []string{}
-	for _, pair := range h.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	for _, pair := range h.Pairs() {
+		pairs = append(pairs, fmt.Sprintf("%s: %s", inspect(pair.Key, seen), inspect(pair.Value, seen)))
 	}
 
 	out.WriteString("{")
@@ -42,3 +125,63 @@ func (h *Hash) Inspect() string {
 	out.WriteString("}")
 	return out.String()
 }
+
+// ToHashable returns the form of o that can be used as a hash key. Arrays are frozen
+// into tuples, so that changing the array afterwards does not change the key. An array
+// that contains itself has no such form.
+func ToHashable(o Object) (Hashable, bool) {
+	return toHashable(o, map[*Array]bool{})
+}
+
+// toHashable freezes o, with freezing holding the arrays it is inside of
+func toHashable(o Object, freezing map[*Array]bool) (Hashable, bool) {
+	switch o := o.(type) {
+	case *Array:
+		if freezing[o] {
+			return nil, false
+		}
+		freezing[o] = true
+		defer delete(freezing, o)
+
+		elements := make([]Object, len(o.Elements))
+		for i, el := range o.Elements {
+			key, ok := toHashable(el, freezing)
+			if !ok {
+				return nil, false
+			}
+			elements[i] = key
+		}
+		return &Tuple{Elements: elements}, true
+	case Hashable:
+		return o, true
+	default:
+		return nil, false
+	}
+}
+
+// keysEqual reports whether two hash keys are the same key. Unlike Equal, keys of
+// different types never match, so 1 and 1.0 are separate keys just as they have
+// separate HashKeys.
+func keysEqual(a, b Object) bool {
+	if a.Type() != b.Type() {
+		return false
+	}
+	switch a := a.(type) {
+	case *Tuple:
+		b := b.(*Tuple)
+		if len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i := range a.Elements {
+			if !keysEqual(a.Elements[i], b.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	case *Float:
+		return a.HashKey() == b.(*Float).HashKey()
+	default:
+		equal, _ := Equal(a, b, nil)
+		return equal
+	}
+}
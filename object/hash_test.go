@@ -16,3 +16,144 @@ func TestStringHashKey(t *testing.T) {
 	assert.Equal(t, diff1.HashKey(), diff2.HashKey())
 	assert.NotEqual(t, hello1.HashKey(), diff1.HashKey())
 }
+
+func TestFloatHashKey(t *testing.T) {
+	assert.Equal(t, (&Float{Value: 1.5}).HashKey(), (&Float{Value: 1.5}).HashKey())
+	assert.NotEqual(t, (&Float{Value: 1.5}).HashKey(), (&Float{Value: 2.5}).HashKey())
+	assert.NotEqual(t, (&Float{Value: 1}).HashKey(), (&Integer{Value: 1}).HashKey())
+}
+
+// collider is a key whose HashKey is always the same, to force collisions
+type collider struct {
+	name string
+}
+
+func (c *collider) Type() ObjectType { return "COLLIDER" }
+func (c *collider) Inspect() string  { return c.name }
+func (c *collider) HashKey() HashKey { return HashKey{Type: c.Type(), Value: 42} }
+
+func (c *collider) Equals(other Object) bool {
+	o, ok := other.(*collider)
+	return ok && o.name == c.name
+}
+
+func TestHashCollisions(t *testing.T) {
+	h := NewHash()
+	a, b := &collider{name: "a"}, &collider{name: "b"}
+	assert.Equal(t, a.HashKey(), b.HashKey())
+
+	h.Set(a, &Integer{Value: 1})
+	h.Set(b, &Integer{Value: 2})
+	assert.Equal(t, 2, h.Len())
+
+	value, ok := h.Get(&collider{name: "a"})
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), value.(*Integer).Value)
+	value, ok = h.Get(&collider{name: "b"})
+	assert.True(t, ok)
+	assert.Equal(t, int64(2), value.(*Integer).Value)
+	_, ok = h.Get(&collider{name: "c"})
+	assert.False(t, ok)
+
+	// Replacing a value in a shared bucket leaves the other key alone
+	h.Set(&collider{name: "b"}, &Integer{Value: 3})
+	assert.Equal(t, 2, h.Len())
+	value, _ = h.Get(a)
+	assert.Equal(t, int64(1), value.(*Integer).Value)
+	value, _ = h.Get(b)
+	assert.Equal(t, int64(3), value.(*Integer).Value)
+	assert.Equal(t, 2, len(h.Pairs()))
+}
+
+func TestTupleHashKey(t *testing.T) {
+	t1 := &Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	t2 := &Tuple{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	t3 := &Tuple{Elements: []Object{&String{Value: "a"}, &Integer{Value: 1}}}
+	t4 := &Tuple{Elements: []Object{&Float{Value: 1}, &String{Value: "a"}}}
+
+	assert.Equal(t, t1.HashKey(), t2.HashKey())
+	assert.NotEqual(t, t1.HashKey(), t3.HashKey())
+	assert.NotEqual(t, t1.HashKey(), t4.HashKey())
+
+	h := NewHash()
+	h.Set(t1, TRUE)
+	_, ok := h.Get(t2)
+	assert.True(t, ok)
+	_, ok = h.Get(t4)
+	assert.False(t, ok)
+}
+
+func TestToHashable(t *testing.T) {
+	arr := &Array{Elements: []Object{&Integer{Value: 1}, &Array{Elements: []Object{TRUE}}}}
+	key, ok := ToHashable(arr)
+	assert.True(t, ok)
+	assert.Equal(t, "(1, (true))", key.Inspect())
+
+	// Later changes to the array do not reach the frozen key
+	arr.Elements[0] = &Integer{Value: 2}
+	assert.Equal(t, "(1, (true))", key.Inspect())
+
+	_, ok = ToHashable(&Array{Elements: []Object{&Array{Elements: []Object{NewHash()}}}})
+	assert.False(t, ok)
+
+	cycle := &Array{Elements: []Object{&Integer{Value: 1}}}
+	cycle.Elements = append(cycle.Elements, &Array{Elements: []Object{cycle}})
+	_, ok = ToHashable(cycle)
+	assert.False(t, ok)
+
+	// An array appearing twice without containing itself is frozen twice
+	shared := &Array{Elements: []Object{TRUE}}
+	key, ok = ToHashable(&Array{Elements: []Object{shared, shared}})
+	assert.True(t, ok)
+	assert.Equal(t, "((true), (true))", key.Inspect())
+}
+
+func TestHashInsertionOrder(t *testing.T) {
+	h := NewHash()
+	for _, key := range []string{"c", "a", "b"} {
+		h.Set(&String{Value: key}, &Integer{Value: int64(len(key))})
+	}
+	h.Set(&String{Value: "a"}, &Integer{Value: 5})
+	h.Set(&Integer{Value: 1}, TRUE)
+
+	keys := []string{}
+	for _, pair := range h.Pairs() {
+		keys = append(keys, pair.Key.Inspect())
+	}
+	assert.Equal(t, []string{"c", "a", "b", "1"}, keys)
+	assert.Equal(t, "{c: 1, a: 5, b: 1, 1: true}", h.Inspect())
+}
+
+func TestHashDelete(t *testing.T) {
+	h := NewHash()
+	for _, key := range []string{"a", "b", "c", "d"} {
+		h.Set(&String{Value: key}, TRUE)
+	}
+	pairs := h.Pairs()
+
+	assert.True(t, h.Delete(&String{Value: "b"}))
+	assert.False(t, h.Delete(&String{Value: "b"}))
+	assert.Equal(t, 3, h.Len())
+	assert.Equal(t, "{a: true, c: true, d: true}", h.Inspect())
+	_, ok := h.Get(&String{Value: "d"})
+	assert.True(t, ok)
+
+	// Pairs handed out before the delete are unchanged
+	assert.Equal(t, 4, len(pairs))
+	assert.Equal(t, "b", pairs[1].Key.Inspect())
+}
+
+func TestInspectCycles(t *testing.T) {
+	a := &Array{Elements: []Object{&Integer{Value: 1}}}
+	a.Elements = append(a.Elements, a)
+	assert.Equal(t, "[1, [...]]", a.Inspect())
+
+	h := NewHash()
+	h.Set(&String{Value: "self"}, h)
+	h.Set(&String{Value: "list"}, &Array{Elements: []Object{h, a}})
+	assert.Equal(t, "{self: {...}, list: [{...}, [1, [...]]]}", h.Inspect())
+
+	// A value that appears twice without containing itself is printed in full
+	shared := &Array{Elements: []Object{TRUE}}
+	assert.Equal(t, "[[true], [true]]", (&Array{Elements: []Object{shared, shared}}).Inspect())
+}
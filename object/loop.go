@@ -0,0 +1,33 @@
+package object
+
+const (
+	BREAK_OBJ    = "BREAK"
+	CONTINUE_OBJ = "CONTINUE"
+)
+
+// Break and Continue carry loop control flow up through the evaluation of
+// nested blocks, the same way ReturnValue does for functions
+var (
+	BREAK    = &Break{}
+	CONTINUE = &Continue{}
+)
+
+type Break struct{}
+
+func (b *Break) Type() ObjectType {
+	return BREAK_OBJ
+}
+
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+type Continue struct{}
+
+func (c *Continue) Type() ObjectType {
+	return CONTINUE_OBJ
+}
+
+func (c *Continue) Inspect() string {
+	return "continue"
+}
@@ -0,0 +1,25 @@
+package object
+
+const MODULE_OBJ = "MODULE"
+
+// Bindings looks up variables by name. It is how a Module reaches the top level
+// bindings of its program, wherever the engine that ran the program keeps them.
+type Bindings interface {
+	Get(name string) (Object, bool)
+}
+
+// Module is the result of importing a source file, its top level bindings live in Env.
+// Env is looked up every time a member is accessed, so a module's own functions can
+// still change its members after it is imported.
+type Module struct {
+	Name string
+	Env  Bindings
+}
+
+func (m *Module) Type() ObjectType {
+	return MODULE_OBJ
+}
+
+func (m *Module) Inspect() string {
+	return "module(" + m.Name + ")"
+}
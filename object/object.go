@@ -5,4 +5,27 @@ type ObjectType = string
 type Object interface {
 	Type() ObjectType
 	Inspect() string
-}
\ No newline at end of file
+}
+
+// inspect returns the Inspect form of o. Arrays and hashes may contain themselves, so
+// one that is already being inspected further up is shown as [...] or {...} instead.
+func inspect(o Object, seen map[Object]bool) string {
+	switch o := o.(type) {
+	case *Array:
+		if seen[o] {
+			return "[...]"
+		}
+		seen[o] = true
+		defer delete(seen, o)
+		return o.inspect(seen)
+	case *Hash:
+		if seen[o] {
+			return "{...}"
+		}
+		seen[o] = true
+		defer delete(seen, o)
+		return o.inspect(seen)
+	default:
+		return o.Inspect()
+	}
+}
@@ -0,0 +1,46 @@
+package object
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/fnv"
+	"strings"
+)
+
+const TUPLE_OBJ = "TUPLE"
+
+// Tuple is an immutable sequence of hashable values, which makes it usable as a hash key
+type Tuple struct {
+	Elements []Object
+}
+
+func (_ *Tuple) Type() ObjectType {
+	return TUPLE_OBJ
+}
+
+func (t *Tuple) Inspect() string {
+	var out bytes.Buffer
+	elements := []string{}
+	for _, e := range t.Elements {
+		elements = append(elements, e.Inspect())
+	}
+
+	out.WriteString("(")
+	out.WriteString(strings.Join(elements, ", "))
+	out.WriteString(")")
+	return out.String()
+}
+
+// HashKey combines the HashKeys of the elements, so tuples with the same contents
+// hash the same
+func (t *Tuple) HashKey() HashKey {
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, e := range t.Elements {
+		key := e.(Hashable).HashKey()
+		h.Write([]byte(key.Type))
+		binary.LittleEndian.PutUint64(buf[:], key.Value)
+		h.Write(buf[:])
+	}
+	return HashKey{Type: t.Type(), Value: h.Sum64()}
+}
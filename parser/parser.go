@@ -4,8 +4,10 @@ import (
 	"bellamy/ast"
 	"bellamy/lexer"
 	"bellamy/token"
+	"errors"
 	"fmt"
 	"strconv"
+	"strings"
 )
 
 type Parser struct {
@@ -15,6 +17,10 @@ type Parser struct {
 	curToken  token.Token
 	peekToken token.Token
 
+	// loopDepth counts the loops enclosing the current token within the current
+	// function, break and continue are only valid when it is above zero
+	loopDepth int
+
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 }
@@ -30,28 +36,49 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TEMPLATE, p.parseTemplateLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
+	p.registerPrefix(token.NULL, p.parseNullLiteral)
 	p.registerPrefix(token.LPAREN, p.parseGroupedExpression)
 	p.registerPrefix(token.IF, p.parseIfExpression)
 	p.registerPrefix(token.FUNCTION, p.parseFunctionLiteral)
 	p.registerPrefix(token.LBRACKET, p.parseArrayLiteral)
 	p.registerPrefix(token.LBRACE, p.parseHashLiteral)
+	p.registerPrefix(token.IMPORT, p.parseImportExpression)
+	p.registerPrefix(token.ILLEGAL, p.parseIllegalToken)
+	p.registerPrefix(token.ERROR, p.parseIllegalToken)
 	// infix registration
 	p.infixParseFns = make(map[token.TokenType]infixParseFn)
 	p.registerInfix(token.PLUS, p.parseInfixExpression)
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NE, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
+	p.registerInfix(token.LE, p.parseInfixExpression)
+	p.registerInfix(token.GE, p.parseInfixExpression)
+	p.registerInfix(token.AND, p.parseInfixExpression)
+	p.registerInfix(token.OR, p.parseInfixExpression)
+	p.registerInfix(token.NULLISH, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.OPTIONAL_LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.PERIOD, p.parseMemberExpression)
+	p.registerInfix(token.OPTIONAL_PERIOD, p.parseMemberExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PERCENT_ASSIGN, p.parseAssignExpression)
 
 	// Read two tokens so curToken and peekToken are both set
 	p.nextToken()
@@ -96,6 +123,12 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK, token.CONTINUE:
+		return p.parseLoopControlStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -129,6 +162,16 @@ func (p *Parser) parseExpression(precedence int) ast.Expression {
 	return leftExp
 }
 
+// parseIllegalToken reports source the lexer could not make sense of
+func (p *Parser) parseIllegalToken() ast.Expression {
+	if p.curTokenIs(token.ERROR) {
+		p.errorf(p.curToken.Pos, "%s", p.curToken.Literal)
+	} else {
+		p.errorf(p.curToken.Pos, "illegal character %q", p.curToken.Literal)
+	}
+	return nil
+}
+
 func (p *Parser) parseIdentifier() ast.Expression {
 	return &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 }
@@ -180,43 +223,131 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
+	// A function body starts outside of any loop, even when defined inside one
+	outerLoopDepth := p.loopDepth
+	p.loopDepth = 0
 	lit.Body = p.parseBlockStatement()
+	p.loopDepth = outerLoopDepth
 
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	args := []*ast.Identifier{}
+// parseFunctionParameters parses a parameter list such as `(a, b = 2, ...rest)`.
+// Parameters with defaults must come after those without, and a rest parameter last.
+func (p *Parser) parseFunctionParameters() []*ast.Parameter {
+	params := []*ast.Parameter{}
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return args
+		return params
 	}
 
-	p.nextToken()
+	for {
+		p.nextToken()
+		param := p.parseParameter()
+		if param == nil {
+			return nil
+		}
+		if n := len(params); n > 0 {
+			prev := params[n-1]
+			if prev.Rest {
+				p.errorf(param.Pos(), "rest parameter %s must be the last parameter", prev)
+				return nil
+			}
+			if prev.Default != nil && param.Default == nil && !param.Rest {
+				p.errorf(param.Pos(), "parameter %s without a default follows parameter %s", param, prev)
+				return nil
+			}
+		}
+		params = append(params, param)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
 
-	arg := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-	args = append(args, arg)
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return params
+}
 
-	for p.peekTokenIs(token.COMMA) {
+func (p *Parser) parseParameter() *ast.Parameter {
+	param := &ast.Parameter{}
+	if p.curTokenIs(token.ELLIPSIS) {
+		param.Rest = true
 		p.nextToken()
+	}
+	if !p.curTokenIs(token.IDENT) {
+		p.errorf(p.curToken.Pos, "expected parameter name, got %s", p.curToken.Type)
+		return nil
+	}
+	param.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.ASSIGN) {
+		if param.Rest {
+			p.errorf(p.peekToken.Pos, "rest parameter %s cannot have a default", param)
+			return nil
+		}
 		p.nextToken()
-		arg := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
-		args = append(args, arg)
+		p.nextToken()
+		param.Default = p.parseExpression(LOWEST)
 	}
+	return param
+}
 
+func (p *Parser) parseImportExpression() ast.Expression {
+	exp := &ast.ImportExpression{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	p.nextToken()
+	exp.Path = p.parseExpression(LOWEST)
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
-
-	return args
+	return exp
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{Token: p.curToken, Function: function}
-	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	p.parseCallArguments(exp)
 	return exp
 }
 
+// parseCallArguments parses the arguments of call, where any named arguments like
+// `b: 3` must follow the positional ones
+func (p *Parser) parseCallArguments(call *ast.CallExpression) {
+	call.Arguments = []ast.Expression{}
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		return
+	}
+
+	for {
+		p.nextToken()
+		if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.COLON) {
+			arg := &ast.NamedArgument{Name: &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}}
+			p.nextToken()
+			p.nextToken()
+			arg.Value = p.parseExpression(LOWEST)
+			call.NamedArguments = append(call.NamedArguments, arg)
+		} else {
+			if len(call.NamedArguments) > 0 {
+				p.errorf(p.curToken.Pos, "positional argument follows named argument")
+				return
+			}
+			call.Arguments = append(call.Arguments, p.parseExpression(LOWEST))
+		}
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
+	}
+	p.expectPeek(token.RPAREN)
+}
+
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 	array.Elements = p.parseExpressionList(token.RBRACKET)
@@ -224,8 +355,7 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 }
 
 func (p *Parser) parseHashLiteral() ast.Expression {
-	hash := &ast.HashLiteral{Token: p.curToken}
-	hash.Pairs = make(map[ast.Expression]ast.Expression)
+	hash := &ast.HashLiteral{Token: p.curToken, Pairs: []ast.HashPair{}}
 
 	for !p.peekTokenIs(token.RBRACE) {
 		p.nextToken()
@@ -235,7 +365,7 @@ func (p *Parser) parseHashLiteral() ast.Expression {
 		}
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
-		hash.Pairs[key] = value
+		hash.Pairs = append(hash.Pairs, ast.HashPair{Key: key, Value: value})
 		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
 			return nil
 		}
@@ -286,33 +416,128 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	return block
 }
 
+// parseIndexExpression parses both index expressions like `a[i]` and slices like `a[i:j]`,
+// where either end of the slice may be left out
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
-	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
-	p.nextToken()
-	exp.Index = p.parseExpression(LOWEST)
+	tok := p.curToken
+	optional := tok.Type == token.OPTIONAL_LBRACKET
+	var index ast.Expression
+	if !p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		index = p.parseExpression(LOWEST)
+	}
+
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		slice := &ast.SliceExpression{Token: tok, Left: left, Start: index, Optional: optional}
+		if !p.peekTokenIs(token.RBRACKET) {
+			p.nextToken()
+			slice.End = p.parseExpression(LOWEST)
+		}
+		if !p.expectPeek(token.RBRACKET) {
+			return nil
+		}
+		return slice
+	}
+
+	exp := &ast.IndexExpression{Token: tok, Left: left, Index: index, Optional: optional}
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
 	return exp
 }
 
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{
+		Token:    p.curToken,
+		Object:   object,
+		Optional: p.curTokenIs(token.OPTIONAL_PERIOD),
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	return exp
+}
+
 func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
+	// Base 0 accepts the 0x, 0o and 0b prefixes and _ separators
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		p.errorf(p.curToken.Pos, "integer literal %s overflows INTEGER", p.curToken.Literal)
+		return nil
+	}
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorf(p.curToken.Pos, "could not parse %q as integer", p.curToken.Literal)
 		return nil
 	}
 	lit.Value = value
 	return lit
 }
 
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.errorf(p.curToken.Pos, "could not parse %q as float", p.curToken.Literal)
+		return nil
+	}
+	lit.Value = value
+	return lit
+}
+
+func (p *Parser) parseNullLiteral() ast.Expression {
+	return &ast.NullLiteral{Token: p.curToken}
+}
+
 func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+func (p *Parser) parseTemplateLiteral() ast.Expression {
+	tmpl := &ast.TemplateLiteral{Token: p.curToken}
+	raw := p.curToken.Literal
+	parts, err := lexer.SplitTemplate(raw)
+	if err != nil {
+		p.errorf(p.curToken.Pos, "%s", err)
+		return nil
+	}
+
+	for _, part := range parts {
+		if !part.IsExpression {
+			tmpl.Parts = append(tmpl.Parts, &ast.StringLiteral{Token: p.curToken, Value: part.Text})
+			continue
+		}
+
+		// The body of the template starts just after its opening quote
+		pos := p.curToken.Pos
+		pos.Column++
+		for _, ch := range raw[:part.Offset] {
+			if ch == '\n' {
+				pos.Line++
+				pos.Column = 0
+			}
+			pos.Column++
+		}
+		if strings.TrimSpace(part.Text) == "" {
+			p.errorf(pos, "empty interpolation")
+			return nil
+		}
+
+		sub := New(lexer.NewAt(part.Text, pos))
+		exp := sub.parseExpression(LOWEST)
+		if !sub.peekTokenIs(token.EOF) {
+			sub.errorf(sub.peekToken.Pos, "unexpected %s in interpolation", sub.peekToken.Type)
+		}
+		p.errors = append(p.errors, sub.errors...)
+		tmpl.Parts = append(tmpl.Parts, exp)
+	}
+	return tmpl
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -336,6 +561,32 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	exp := &ast.AssignExpression{
+		Token:    p.curToken,
+		Operator: p.curToken.Literal,
+		Target:   target,
+	}
+	valid := false
+	switch target := target.(type) {
+	case *ast.Identifier:
+		valid = true
+	case *ast.IndexExpression:
+		valid = !target.Optional
+	case *ast.MemberExpression:
+		valid = !target.Optional
+	}
+	if !valid {
+		p.errorf(p.curToken.Pos, "cannot assign to %s", target)
+		return nil
+	}
+
+	p.nextToken()
+	// Parsing the value at the lowest precedence makes assignment right associative
+	exp.Value = p.parseExpression(LOWEST)
+	return exp
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
 	p.nextToken()
 
@@ -361,6 +612,9 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 	p.nextToken()
 
 	stmt.Value = p.parseExpression(LOWEST)
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fl.Name = stmt.Name.Value
+	}
 
 	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
@@ -378,9 +632,91 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 	return stmt
 }
 
+// errorf records a parser error prefixed with the source position it occurred at
+func (p *Parser) errorf(pos token.Position, format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	p.errors = append(p.errors, pos.String()+": "+msg)
+}
+
+func (p *Parser) parseWhileStatement() ast.Statement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseForStatement() ast.Statement {
+	stmt := &ast.ForStatement{Token: p.curToken}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Variable = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	body := p.parseBlockStatement()
+	p.loopDepth--
+	return body
+}
+
+func (p *Parser) parseLoopControlStatement() ast.Statement {
+	if p.loopDepth == 0 {
+		p.errorf(p.curToken.Pos, "%s outside of a loop", p.curToken.Literal)
+	}
+
+	var stmt ast.Statement
+	if p.curTokenIs(token.BREAK) {
+		stmt = &ast.BreakStatement{Token: p.curToken}
+	} else {
+		stmt = &ast.ContinueStatement{Token: p.curToken}
+	}
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+	return stmt
+}
+
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("no prefix parse function exists for %s", t)
-	p.errors = append(p.errors, msg)
+	p.errorf(p.curToken.Pos, "no prefix parse function exists for %s", t)
 }
 
 func (p *Parser) curTokenIs(tokenType token.TokenType) bool {
@@ -392,8 +728,7 @@ func (p *Parser) peekTokenIs(tokenType token.TokenType) bool {
 }
 
 func (p *Parser) peekError(tokenType token.TokenType) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s", tokenType, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	p.errorf(p.peekToken.Pos, "expected next token to be %s, got %s", tokenType, p.peekToken.Type)
 }
 
 func (p *Parser) expectPeek(tokenType token.TokenType) bool {
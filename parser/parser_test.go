@@ -59,7 +59,35 @@ func TestParserErrors(t *testing.T) {
 	p.ParseProgram()
 	// we expect errors here
 	es := checkParserErrors(t, p, true)
-	assert.Equal(t, "expected next token to be =, got INT", es[0])
+	assert.Equal(t, "2:8: expected next token to be =, got INT", es[0])
+}
+
+func TestParserErrorsIncludeFile(t *testing.T) {
+	l := lexer.NewWithFile("let x = (1 + 2;", "main.bel")
+	p := New(l)
+
+	p.ParseProgram()
+	es := checkParserErrors(t, p, true)
+	assert.Equal(t, "main.bel:1:15: expected next token to be ), got ;", es[0])
+}
+
+func TestLexerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 5 & 3;", "1:11: illegal character \"&\""},
+		{"let x = 5;\n/* unfinished", "2:1: unterminated block comment"},
+		{"let x = \"unfinished;", "1:9: unterminated string"},
+		{"let x = \"\\x\";", "1:9: invalid escape sequence \\x"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		es := checkParserErrors(t, p, true)
+		assert.Equal(t, tt.expected, es[0])
+	}
 }
 
 func TestReturnStatements(t *testing.T) {
@@ -117,6 +145,15 @@ func TestBoolean(t *testing.T) {
 	assert.Equal(t, "true", b.TokenLiteral())
 }
 
+func TestNullLiteral(t *testing.T) {
+	program := SetupParserTest(t, "null;")
+	assert.Equal(t, 1, len(program.Statements))
+	stmt := program.Statements[0].(*ast.ExpressionStatement)
+	_, ok := stmt.Expression.(*ast.NullLiteral)
+	assert.True(t, ok, "exp not *ast.NullLiteral, got %T", stmt.Expression)
+	assert.Equal(t, "null", stmt.Expression.TokenLiteral())
+}
+
 func TestIntegerLiteralExpressions(t *testing.T) {
 	input := "5;"
 	numStatements := 1
@@ -131,6 +168,60 @@ func TestIntegerLiteralExpressions(t *testing.T) {
 	assert.Equal(t, "5", literal.TokenLiteral())
 }
 
+func TestIntegerLiteralForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"0xFF", 255},
+		{"0Xff", 255},
+		{"0o17", 15},
+		{"0b1010", 10},
+		{"1_000_000", 1000000},
+		{"0b_1111_0000", 240},
+		{"9223372036854775807", 9223372036854775807},
+	}
+
+	for _, tt := range tests {
+		program := SetupParserTest(t, tt.input)
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		assert.True(t, ok, "exp not IntegerLiteral, got %T", stmt.Expression)
+		assert.Equal(t, tt.expected, literal.Value, tt.input)
+		assert.Equal(t, tt.input, literal.TokenLiteral())
+	}
+}
+
+func TestIntegerLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let x = 9223372036854775808;", "1:9: integer literal 9223372036854775808 overflows INTEGER"},
+		{"let x = 1;\nx + 0x1_0000_0000_0000_0000;", "2:5: integer literal 0x1_0000_0000_0000_0000 overflows INTEGER"},
+		{"let x = 0b102;", "1:9: could not parse \"0b102\" as integer"},
+		{"let x = 1__0;", "1:9: could not parse \"1__0\" as integer"},
+		{"let x = 0x;", "1:9: could not parse \"0x\" as integer"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		es := checkParserErrors(t, p, true)
+		assert.Equal(t, tt.expected, es[0], tt.input)
+	}
+}
+
+func TestFloatLiteralExpressions(t *testing.T) {
+	program := SetupParserTest(t, "3.25;")
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	assert.True(t, ok)
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	assert.True(t, ok)
+	assert.Equal(t, 3.25, literal.Value)
+	assert.Equal(t, "3.25", literal.TokenLiteral())
+}
+
 func TestStringLiteralExpressions(t *testing.T) {
 	input := `"this is a string!"`
 	numStatements := 1
@@ -147,6 +238,37 @@ func TestStringLiteralExpressions(t *testing.T) {
 	assert.Equal(t, "this is a string!", literal.TokenLiteral())
 }
 
+func TestTemplateLiteral(t *testing.T) {
+	program := SetupParserTest(t, `"total: ${count * 2}!"`)
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	assert.True(t, ok)
+	tmpl, ok := stmt.Expression.(*ast.TemplateLiteral)
+	assert.True(t, ok)
+	assert.Equal(t, 3, len(tmpl.Parts))
+	assert.Equal(t, "total: ", tmpl.Parts[0].(*ast.StringLiteral).Value)
+	testInfixExpression(t, tmpl.Parts[1], "count", "*", 2)
+	assert.Equal(t, "!", tmpl.Parts[2].(*ast.StringLiteral).Value)
+	assert.Equal(t, `"total: ${(count * 2)}!"`, tmpl.String())
+}
+
+func TestTemplateLiteralErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`let s = "a ${1 +} b";`, "1:17: no prefix parse function exists for EOF"},
+		{"let s = \"line\n${x y}\";", "2:5: unexpected IDENT in interpolation"},
+		{`let s = "${ }";`, "1:12: empty interpolation"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		es := checkParserErrors(t, p, true)
+		assert.Equal(t, tt.expected, es[0], tt.input)
+	}
+}
+
 func TestParsingPrefixExpression(t *testing.T) {
 	prefixTests := []struct {
 		input        string
@@ -322,6 +444,38 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"a || b && c == d",
+			"(a || (b && (c == d)))",
+		},
+		{
+			"a <= b && c >= d || !e",
+			"(((a <= b) && (c >= d)) || (!e))",
+		},
+		{
+			"-a.b(c) * d",
+			"((-a.b(c)) * d)",
+		},
+		{
+			"a.b.c[1] + x.y()",
+			"((a.b.c[1]) + x.y())",
+		},
+		{
+			"a ?? b || c",
+			"(a ?? (b || c))",
+		},
+		{
+			"x = a ?? b ?? c",
+			"x = ((a ?? b) ?? c)",
+		},
+		{
+			"a?.b?[1].c() ?? d",
+			"((a?.b?[1]).c() ?? d)",
+		},
+		{
+			"s?[1:] == null",
+			"((s?[1:]) == null)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -388,8 +542,8 @@ func TestFunctionLiteralParsing(t *testing.T) {
 	assert.True(t, ok)
 	assert.Equal(t, 2, len(function.Parameters))
 
-	testLiteralExpression(t, function.Parameters[0], "x")
-	testLiteralExpression(t, function.Parameters[1], "y")
+	testLiteralExpression(t, function.Parameters[0].Name, "x")
+	testLiteralExpression(t, function.Parameters[1].Name, "y")
 
 	assert.Equal(t, 1, len(function.Body.Statements))
 
@@ -399,6 +553,14 @@ func TestFunctionLiteralParsing(t *testing.T) {
 	testInfixExpression(t, bodyStmt.Expression, "x", "+", "y")
 }
 
+func TestFunctionLiteralName(t *testing.T) {
+	program := SetupParserTest(t, "let add = fn(a, b) { a + b }; fn(x) { x }")
+	let := program.Statements[0].(*ast.LetStatement)
+	assert.Equal(t, "add", let.Value.(*ast.FunctionLiteral).Name)
+	anonymous := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	assert.Equal(t, "", anonymous.Name)
+}
+
 func TestFunctionParameterParsing(t *testing.T) {
 	tests := []struct {
 		input          string
@@ -418,11 +580,62 @@ func TestFunctionParameterParsing(t *testing.T) {
 		assert.Equal(t, len(tt.expectedParams), len(function.Parameters))
 
 		for i, ident := range tt.expectedParams {
-			testLiteralExpression(t, function.Parameters[i], ident)
+			testLiteralExpression(t, function.Parameters[i].Name, ident)
 		}
 	}
 }
 
+func TestParameterForms(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(a, b = 2) {}", "fn(a, b = 2) "},
+		{"fn(first, ...rest) {}", "fn(first, ...rest) "},
+		{"fn(a = 1, b = a * 2, ...rest) {}", "fn(a = 1, b = (a * 2), ...rest) "},
+		{"f(1, b: 2, c: x + 1)", "f(1, b: 2, c: (x + 1))"},
+		{"f(b: [1, 2][0])", "f(b: ([1, 2][0]))"},
+	}
+
+	for _, tt := range tests {
+		program := SetupParserTest(t, tt.input)
+		assert.Equal(t, tt.expected, program.String(), tt.input)
+	}
+
+	program := SetupParserTest(t, "fn(a, b = 2, ...c) {}")
+	function := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.FunctionLiteral)
+	assert.Nil(t, function.Parameters[0].Default)
+	testIntegerLiteral(t, function.Parameters[1].Default, 2)
+	assert.True(t, function.Parameters[2].Rest)
+
+	program = SetupParserTest(t, "f(1, b: 2)")
+	call := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	assert.Equal(t, 1, len(call.Arguments))
+	assert.Equal(t, 1, len(call.NamedArguments))
+	testIdentifier(t, call.NamedArguments[0].Name, "b")
+	testIntegerLiteral(t, call.NamedArguments[0].Value, 2)
+}
+
+func TestParameterErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fn(...rest, a) {}", "1:13: rest parameter ...rest must be the last parameter"},
+		{"fn(a = 1, b) {}", "1:11: parameter b without a default follows parameter a = 1"},
+		{"fn(...rest = []) {}", "1:12: rest parameter ...rest cannot have a default"},
+		{"fn(1) {}", "1:4: expected parameter name, got INT"},
+		{"f(a: 1, 2)", "1:9: positional argument follows named argument"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		es := checkParserErrors(t, p, true)
+		assert.Equal(t, tt.expected, es[0], tt.input)
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
 	program := SetupParserTest(t, input)
@@ -466,20 +679,51 @@ func TestIndexExpressions(t *testing.T) {
 	testInfixExpression(t, indexExp.Index, 1, "+", 1)
 }
 
+func TestSliceExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"s[1:3]", "(s[1:3])"},
+		{"s[:i + 1]", "(s[:(i + 1)])"},
+		{"s[2:]", "(s[2:])"},
+		{"s[:]", "(s[:])"},
+		{"a * b[1:][0]", "(a * ((b[1:])[0]))"},
+	}
+
+	for _, tt := range tests {
+		program := SetupParserTest(t, tt.input)
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		assert.Equal(t, tt.expected, stmt.Expression.String(), tt.input)
+	}
+
+	program := SetupParserTest(t, "myArray[1:2 + 3]")
+	slice, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.SliceExpression)
+	assert.True(t, ok)
+	testIdentifier(t, slice.Left, "myArray")
+	testIntegerLiteral(t, slice.Start, 1)
+	testInfixExpression(t, slice.End, 2, "+", 3)
+}
+
 func TestHashLiteralsStringKeys(t *testing.T) {
-	input := `{"one": 1, "two": 2 }`
+	input := `{"one": 1, "two": 2, "three": 3 }`
 	program := SetupParserTest(t, input)
 	stmt := program.Statements[0].(*ast.ExpressionStatement)
 	hash, ok := stmt.Expression.(*ast.HashLiteral)
 	assert.True(t, ok)
-	assert.Equal(t, 2, len(hash.Pairs))
-	expected := map[string]int64{"one": 1, "two": 2}
-	for k, v := range hash.Pairs {
-		literal, ok := k.(*ast.StringLiteral)
+	assert.Equal(t, 3, len(hash.Pairs))
+	// Pairs keep the order they were written in
+	expected := []struct {
+		key   string
+		value int64
+	}{{"one", 1}, {"two", 2}, {"three", 3}}
+	for i, pair := range hash.Pairs {
+		literal, ok := pair.Key.(*ast.StringLiteral)
 		assert.True(t, ok)
-		expectedVal := expected[literal.String()]
-		testIntegerLiteral(t, v, expectedVal)
+		assert.Equal(t, expected[i].key, literal.String())
+		testIntegerLiteral(t, pair.Value, expected[i].value)
 	}
+	assert.Equal(t, "{one:1, two:2, three:3}", hash.String())
 }
 
 func TestEmptyHashLiteral(t *testing.T) {
@@ -491,6 +735,129 @@ func TestEmptyHashLiteral(t *testing.T) {
 	assert.Equal(t, 0, len(hash.Pairs))
 }
 
+func TestMemberExpressions(t *testing.T) {
+	input := "[1, 2].push(3)"
+	program := SetupParserTest(t, input)
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	assert.True(t, ok)
+	call, ok := stmt.Expression.(*ast.CallExpression)
+	assert.True(t, ok)
+	member, ok := call.Function.(*ast.MemberExpression)
+	assert.True(t, ok)
+	_, ok = member.Object.(*ast.ArrayLiteral)
+	assert.True(t, ok)
+	testIdentifier(t, member.Property, "push")
+	assert.Equal(t, 1, len(call.Arguments))
+	testIntegerLiteral(t, call.Arguments[0], 3)
+}
+
+func TestAssignExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5", "x = 5"},
+		{"x = y = 1 + 2", "x = y = (1 + 2)"},
+		{"x += y * 2", "x += (y * 2)"},
+		{"arr[i + 1] -= 1", "(arr[(i + 1)]) -= 1"},
+		{"h.count *= 2", "h.count *= 2"},
+		{"x /= 2; x", "x /= 2x"},
+	}
+
+	for _, tt := range tests {
+		program := SetupParserTest(t, tt.input)
+		_, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expected, program.String())
+	}
+
+	errorTests := []struct {
+		input    string
+		expected string
+	}{
+		{"1 = 2", "1:3: cannot assign to 1"},
+		{"a?.b = 2", "1:6: cannot assign to a?.b"},
+		{"a?[0] += 2", "1:7: cannot assign to (a?[0])"},
+	}
+
+	for _, tt := range errorTests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		es := checkParserErrors(t, p, true)
+		assert.Equal(t, tt.expected, es[0], tt.input)
+	}
+}
+
+func TestWhileStatement(t *testing.T) {
+	input := "while (x < y) { x; break; }"
+	program := SetupParserTest(t, input)
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	assert.True(t, ok)
+	testInfixExpression(t, stmt.Condition, "x", "<", "y")
+	assert.Equal(t, 2, len(stmt.Body.Statements))
+	_, ok = stmt.Body.Statements[1].(*ast.BreakStatement)
+	assert.True(t, ok)
+}
+
+func TestForStatement(t *testing.T) {
+	input := "for (x in [1, 2]) { if (x > 1) { continue; } x }"
+	program := SetupParserTest(t, input)
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	assert.True(t, ok)
+	testIdentifier(t, stmt.Variable, "x")
+	_, ok = stmt.Iterable.(*ast.ArrayLiteral)
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(stmt.Body.Statements))
+	assert.Equal(t, "for(x in [1, 2]) if(x > 1) continue;x", program.String())
+}
+
+func TestLoopTrailingSemicolon(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"while (x < 2) { x += 1 }; x", "while(x < 2) x += 1x"},
+		{"for (x in y) { x }; y", "for(x in y) xy"},
+	}
+
+	for _, tt := range tests {
+		program := SetupParserTest(t, tt.input)
+		assert.Equal(t, 2, len(program.Statements), tt.input)
+		assert.Equal(t, tt.expected, program.String(), tt.input)
+	}
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"break;", "1:1: break outside of a loop"},
+		{"if (true) { continue; }", "1:13: continue outside of a loop"},
+		{"while (true) { fn() { break; } }", "1:23: break outside of a loop"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+		es := checkParserErrors(t, p, true)
+		assert.Equal(t, []string{tt.expected}, es)
+	}
+}
+
+func TestImportExpression(t *testing.T) {
+	input := `let m = import("lib/math.bel");`
+	program := SetupParserTest(t, input)
+	stmt, ok := program.Statements[0].(*ast.LetStatement)
+	assert.True(t, ok)
+	imp, ok := stmt.Value.(*ast.ImportExpression)
+	assert.True(t, ok)
+	path, ok := imp.Path.(*ast.StringLiteral)
+	assert.True(t, ok)
+	assert.Equal(t, "lib/math.bel", path.Value)
+	assert.Equal(t, "let m = import(lib/math.bel);", program.String())
+}
+
 // Helper methods
 
 func SetupParserTest(t *testing.T, input string) *ast.Program {
@@ -21,6 +21,7 @@ func StartLexRepl(in io.Reader, out io.Writer) {
 
 		line := scanner.Text()
 		l := lexer.New(line)
+		l.KeepComments = true
 		for t := l.NextToken(); t.Type != token.EOF; t = l.NextToken() {
 			out.Write([]byte(fmt.Sprintf("%+v\n", t)))
 
@@ -3,16 +3,15 @@ package repl
 import (
 	"bellamy/evaluator"
 	"bellamy/lexer"
-	"bellamy/object"
 	"bellamy/parser"
 	"bufio"
 	"fmt"
 	"io"
 )
 
-func StartEvalRepl(in io.Reader, out io.Writer) {
+func StartEvalRepl(in io.Reader, out io.Writer, options evaluator.Options) {
 	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+	env := evaluator.NewEnvironment(options)
 
 	for {
 		fmt.Printf(PROMPT)
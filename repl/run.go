@@ -0,0 +1,58 @@
+package repl
+
+import (
+	"bellamy/evaluator"
+	"bellamy/lexer"
+	"bellamy/object"
+	"bellamy/parser"
+	"bellamy/vm"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// RunFile reads, parses and runs the Bellamy source file at path as selected by config.
+// Any extra command line args are exposed to the script as the `args` array.
+// Parser and runtime errors are written to errOut, and the returned value is
+// the exit code the process should use.
+func RunFile(path string, args []string, config Config, errOut io.Writer) int {
+	source, err := ioutil.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(errOut, "%s\n", err)
+		return 1
+	}
+
+	l := lexer.NewWithFile(string(source), path)
+	p := parser.New(l)
+	program := p.ParseProgram()
+	if len(p.Errors()) > 0 {
+		for _, msg := range p.Errors() {
+			fmt.Fprintf(errOut, "%s\n", msg)
+		}
+		return 1
+	}
+
+	var evaluated object.Object
+	if config.Engine == "vm" {
+		session := vm.NewSession(config.Options)
+		session.Define("args", argsArray(args))
+		evaluated = session.Run(program)
+	} else {
+		env := evaluator.NewEnvironment(config.Options)
+		env.Set("args", argsArray(args))
+		evaluated = evaluator.Eval(program, env)
+	}
+	if errObj, ok := evaluated.(*object.Error); ok {
+		fmt.Fprintf(errOut, "%s\n", errObj.Inspect())
+		return 1
+	}
+	return 0
+}
+
+func argsArray(args []string) *object.Array {
+	elements := make([]object.Object, len(args))
+	for i, arg := range args {
+		elements[i] = &object.String{Value: arg}
+	}
+	return &object.Array{Elements: elements}
+}
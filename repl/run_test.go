@@ -0,0 +1,57 @@
+package repl
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bellamy")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		source   string
+		args     []string
+		code     int
+		expected string
+	}{
+		{`let x = 1 + 2;`, nil, 0, ""},
+		{`if (len(args) != 2 || args[1] != "b") { 1 + true }`, []string{"a", "b"}, 0, ""},
+		{`len(args) + true`, []string{"a"}, 1, "ERROR: %s:1:11: type mismatch: INTEGER + BOOLEAN\n"},
+		{"let x = 1;\nlet 5;", nil, 1, "%s:2:5: expected next token to be IDENT, got INT\n"},
+	}
+
+	for _, engine := range []string{"eval", "vm"} {
+		config := Config{Engine: engine}
+		for i, tt := range tests {
+			path := filepath.Join(dir, "script.bel")
+			assert.NoError(t, ioutil.WriteFile(path, []byte(tt.source), 0644))
+
+			var errOut bytes.Buffer
+			code := RunFile(path, tt.args, config, &errOut)
+			assert.Equal(t, tt.code, code, "%s: test %d", engine, i)
+			expected := tt.expected
+			if expected != "" {
+				expected = fmt.Sprintf(expected, path)
+			}
+			assert.Equal(t, expected, errOut.String(), "%s: test %d", engine, i)
+		}
+
+		path := filepath.Join(dir, "checked.bel")
+		assert.NoError(t, ioutil.WriteFile(path, []byte("9223372036854775807 + 1"), 0644))
+		var errOut bytes.Buffer
+		config.Options.CheckedArithmetic = true
+		assert.Equal(t, 1, RunFile(path, nil, config, &errOut), engine)
+		assert.Equal(t, fmt.Sprintf("ERROR: %s:1:21: integer overflow: 9223372036854775807 + 1\n", path), errOut.String(), engine)
+	}
+	var errOut bytes.Buffer
+	assert.Equal(t, 1, RunFile(filepath.Join(dir, "missing.bel"), nil, Config{Engine: "eval"}, &errOut))
+	assert.Contains(t, errOut.String(), "missing.bel")
+}
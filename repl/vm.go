@@ -0,0 +1,54 @@
+package repl
+
+import (
+	"bellamy/evaluator"
+	"bellamy/lexer"
+	"bellamy/parser"
+	"bellamy/vm"
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Config selects how the REPL and RunFile run programs
+type Config struct {
+	// Engine is "eval" to walk the syntax tree or "vm" to compile it to bytecode first
+	Engine  string
+	Options evaluator.Options
+}
+
+// StartRepl starts the REPL of the Engine selected by config
+func StartRepl(in io.Reader, out io.Writer, config Config) {
+	if config.Engine == "vm" {
+		StartVMRepl(in, out, config.Options)
+		return
+	}
+	StartEvalRepl(in, out, config.Options)
+}
+
+func StartVMRepl(in io.Reader, out io.Writer, options evaluator.Options) {
+	scanner := bufio.NewScanner(in)
+	session := vm.NewSession(options)
+
+	for {
+		fmt.Fprint(out, PROMPT)
+		text := scanner.Scan()
+		if !text {
+			return
+		}
+		line := scanner.Text()
+		l := lexer.New(line)
+		p := parser.New(l)
+		program := p.ParseProgram()
+		if len(p.Errors()) > 0 {
+			printParserErrors(out, p.Errors())
+			continue
+		}
+
+		result := session.Run(program)
+		if result != nil {
+			io.WriteString(out, result.Inspect())
+			io.WriteString(out, "\n")
+		}
+	}
+}
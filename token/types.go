@@ -3,12 +3,19 @@ package token
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
+	// ERROR is produced for malformed source such as an unterminated comment,
+	// its Literal describes the problem
+	ERROR   = "ERROR"
+	COMMENT = "COMMENT"
 
 	// Identifiers and literals
 	IDENT  = "IDENT"
 	INT    = "INT"
+	FLOAT  = "FLOAT"
 	STRING = "STRING"
-	ARRAY  = "ARRAY"
+	// TEMPLATE is a string containing ${...} interpolations, its Literal is the unprocessed source
+	TEMPLATE = "TEMPLATE"
+	ARRAY    = "ARRAY"
 
 	// Operators
 	ASSIGN   = "="
@@ -17,16 +24,34 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
 	LT       = "<"
 	GT       = ">"
+	LE       = "<="
+	GE       = ">="
 	EQ       = "=="
 	NE       = "!="
+	AND      = "&&"
+	OR       = "||"
+	NULLISH  = "??"
+
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+	PERCENT_ASSIGN  = "%="
 
 	// Delimiters
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
 	PERIOD    = "."
+	ELLIPSIS  = "..."
+
+	// OPTIONAL_PERIOD and OPTIONAL_LBRACKET start member and index expressions
+	// that evaluate to null rather than failing when their left side is null
+	OPTIONAL_PERIOD   = "?."
+	OPTIONAL_LBRACKET = "?["
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -40,19 +65,33 @@ const (
 	LET      = "LET"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
+	NULL     = "NULL"
 	RETURN   = "RETURN"
 	IF       = "IF"
 	ELSE     = "ELSE"
+	IMPORT   = "IMPORT"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
 )
 
 var keywords = map[string]TokenType{
-	"fn":     FUNCTION,
-	"let":    LET,
-	"true":   TRUE,
-	"false":  FALSE,
-	"return": RETURN,
-	"if":     IF,
-	"else":   ELSE,
+	"fn":       FUNCTION,
+	"let":      LET,
+	"true":     TRUE,
+	"false":    FALSE,
+	"null":     NULL,
+	"return":   RETURN,
+	"if":       IF,
+	"else":     ELSE,
+	"import":   IMPORT,
+	"while":    WHILE,
+	"for":      FOR,
+	"in":       IN,
+	"break":    BREAK,
+	"continue": CONTINUE,
 }
 
 func LookupIdent(ident string) TokenType {
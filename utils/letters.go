@@ -1,5 +1,8 @@
 package utils
 
-func IsLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+import "unicode"
+
+// IsLetter reports whether ch may appear in an identifier: any Unicode letter, or _
+func IsLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
 }
@@ -8,5 +8,8 @@ import (
 
 func TestIsLetter(t *testing.T) {
 	assert.Equal(t, true, IsLetter('x'))
+	assert.Equal(t, true, IsLetter('é'))
+	assert.Equal(t, true, IsLetter('日'))
+	assert.Equal(t, true, IsLetter('_'))
 	assert.Equal(t, false, IsLetter('4'))
 }
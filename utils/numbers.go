@@ -1,5 +1,5 @@
 package utils
 
-func IsDigit(ch byte) bool {
+func IsDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
@@ -9,4 +9,5 @@ import (
 func TestIsDigit(t *testing.T) {
 	assert.Equal(t, false, IsDigit('x'))
 	assert.Equal(t, true, IsDigit('4'))
+	assert.Equal(t, false, IsDigit('٣'))
 }
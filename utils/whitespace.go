@@ -1,5 +1,5 @@
 package utils
 
-func IsWhitespace(ch byte) bool {
+func IsWhitespace(ch rune) bool {
 	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
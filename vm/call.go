@@ -0,0 +1,92 @@
+package vm
+
+import (
+	"bellamy/evaluator"
+	"bellamy/object"
+)
+
+// call calls the function on the stack below its argc positional arguments and the
+// named arguments given by names. A tail call replaces the frame making it.
+func (vm *VM) call(argc int, names *object.Tuple, tail bool) object.Object {
+	var named []evaluator.NamedArgument
+	if names != nil {
+		named = make([]evaluator.NamedArgument, len(names.Elements))
+		for i, name := range names.Elements {
+			named[i] = evaluator.NamedArgument{
+				Name:  name.(*object.String).Value,
+				Value: vm.stack[vm.sp-len(named)+i],
+			}
+		}
+	}
+	top := vm.sp - len(named)
+	args := vm.stack[top-argc : top]
+	callee := top - argc - 1
+
+	switch fn := vm.stack[callee].(type) {
+	case *Closure:
+		e, err := bind(fn, args, named)
+		if err != nil {
+			return err
+		}
+		if tail {
+			f := &vm.frames[vm.fp-1]
+			vm.sp = f.base
+			f.cl, f.ip, f.env = fn, 0, e
+			return nil
+		}
+		vm.sp = callee
+		if err := vm.pushFrame(fn, e, callee); err != nil {
+			return err
+		}
+		return nil
+	case *object.Builtin:
+		if len(named) > 0 {
+			return object.NewError("builtin functions do not take named arguments, got %s", named[0].Name)
+		}
+		// The arguments are copied, as builtins may hold on to them
+		result := fn.Fn(vm.apply, append([]object.Object(nil), args...)...)
+		vm.sp = callee
+		vm.push(result)
+		return result
+	default:
+		return object.NewError("not a function: %s", fn.Type())
+	}
+}
+
+// apply is the object.Applier handed to builtins
+func (vm *VM) apply(fn object.Object, args ...object.Object) object.Object {
+	switch fn := fn.(type) {
+	case *Closure:
+		e, err := bind(fn, args, nil)
+		if err != nil {
+			return err
+		}
+		stop := vm.fp
+		if err := vm.pushFrame(fn, e, vm.sp); err != nil {
+			return err
+		}
+		return vm.run(stop)
+	case *object.Builtin:
+		return fn.Fn(vm.apply, args...)
+	default:
+		return object.NewError("not a function: %s", fn.Type())
+	}
+}
+
+// bind creates the scope of a call to fn, with the arguments bound to its parameters
+func bind(fn *Closure, args []object.Object, named []evaluator.NamedArgument) (*env, *object.Error) {
+	e := newEnv(fn.Fn.Scope, fn.env)
+	params := fn.Fn.Literal.Parameters
+	if fn.Fn.Simple && len(named) == 0 && len(args) == len(params) {
+		copy(e.slots, args)
+		return e, nil
+	}
+
+	// Parameters left to their defaults stay empty, for the function to fill in
+	values, err := evaluator.BindArguments(params, fn.Describe(), args, named)
+	if err != nil {
+		return nil, err
+	}
+	copy(e.slots, values)
+	return e, nil
+}
@@ -0,0 +1,148 @@
+package vm
+
+import (
+	"bellamy/compiler"
+	"bellamy/object"
+	"bytes"
+	"strings"
+)
+
+// Closure is a compiled function along with the scope it was created in
+type Closure struct {
+	Fn      *compiler.Function
+	env     *env
+	program *program
+}
+
+// Describe names the function for error messages
+func (cl *Closure) Describe() string {
+	if cl.Fn.Literal == nil || cl.Fn.Literal.Name == "" {
+		return "anonymous function"
+	}
+	return "function `" + cl.Fn.Literal.Name + "`"
+}
+
+// Type is the same as that of the functions of the evaluator, as both are simply functions to a program
+func (cl *Closure) Type() object.ObjectType {
+	return object.FUNCTION_OBJ
+}
+
+func (cl *Closure) Inspect() string {
+	if cl.Fn.Literal == nil {
+		return "fn() {}"
+	}
+	var out bytes.Buffer
+	params := []string{}
+	for _, p := range cl.Fn.Literal.Parameters {
+		params = append(params, p.String())
+	}
+
+	out.WriteString("fn")
+	out.WriteString("(")
+	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(") {\n")
+	out.WriteString(cl.Fn.Literal.Body.String())
+	out.WriteString("\n}")
+	return out.String()
+}
+
+// program holds what the closures compiled from the same bytecode share
+type program struct {
+	constants []object.Object
+	globals   *Globals
+}
+
+// env holds the values of the variables of a scope at run time
+type env struct {
+	slots []object.Object
+	outer *env
+	scope *compiler.Scope
+	// inline holds the slots of small scopes, saving a call an allocation
+	inline [4]object.Object
+}
+
+func newEnv(scope *compiler.Scope, outer *env) *env {
+	e := &env{outer: outer, scope: scope}
+	if n := len(scope.Names); n <= len(e.inline) {
+		e.slots = e.inline[:n]
+	} else {
+		e.slots = make([]object.Object, n)
+	}
+	return e
+}
+
+// Globals holds the values of the variables defined at the top level of a program.
+// The REPL keeps them from one line to the next.
+type Globals struct {
+	scope  *compiler.Scope
+	values []object.Object
+}
+
+func NewGlobals() *Globals {
+	return &Globals{}
+}
+
+// Set gives the global at index a value
+func (g *Globals) Set(index int, value object.Object) {
+	g.grow(index + 1)
+	g.values[index] = value
+}
+
+func (g *Globals) grow(size int) {
+	for len(g.values) < size {
+		g.values = append(g.values, nil)
+	}
+}
+
+// lookup finds the variable called name at run time, for code compiled before it was
+// defined. Like the evaluator it searches the scopes from e outwards, then the globals
+// and then the builtins. Variables that have not been given a value yet are passed over.
+func lookup(name string, e *env, g *Globals) (object.Object, bool) {
+	if slot := find(name, e, g); slot != nil {
+		return *slot, true
+	}
+	if builtin, ok := builtins[name]; ok {
+		return builtin, true
+	}
+	return nil, false
+}
+
+// assign updates the variable called name found the same way as by lookup
+func assign(name string, value object.Object, e *env, g *Globals) bool {
+	slot := find(name, e, g)
+	if slot == nil {
+		return false
+	}
+	*slot = value
+	return true
+}
+
+func find(name string, e *env, g *Globals) *object.Object {
+	for ; e != nil; e = e.outer {
+		for i, n := range e.scope.Names {
+			if n == name && e.slots[i] != nil {
+				return &e.slots[i]
+			}
+		}
+	}
+	for i, n := range g.scope.Names {
+		if n == name && i < len(g.values) && g.values[i] != nil {
+			return &g.values[i]
+		}
+	}
+	return nil
+}
+
+// iterator steps through the items of a for loop
+type iterator struct {
+	items []object.Object
+	next  int
+}
+
+func (it *iterator) Type() object.ObjectType {
+	return "ITERATOR"
+}
+
+func (it *iterator) Inspect() string {
+	return "iterator"
+}
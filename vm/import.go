@@ -0,0 +1,25 @@
+package vm
+
+import (
+	"bellamy/ast"
+	"bellamy/object"
+)
+
+// runModule runs the program of a module imported by the session in a Session of its
+// own that shares its options and the modules imported so far. That Session then serves as the
+// bindings of the module, so its members are read from its globals as they stand.
+func (s *Session) runModule(program *ast.Program) (object.Bindings, object.Object) {
+	module := NewSession(s.options)
+	module.modules = s.modules
+	return module, module.Run(program)
+}
+
+// Get looks up a global variable of the session that has been given a value
+func (s *Session) Get(name string) (object.Object, bool) {
+	symbol, ok := s.symbols.Resolve(name)
+	if !ok || symbol.Index >= len(s.globals.values) {
+		return nil, false
+	}
+	value := s.globals.values[symbol.Index]
+	return value, value != nil
+}
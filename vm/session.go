@@ -0,0 +1,60 @@
+package vm
+
+import (
+	"bellamy/ast"
+	"bellamy/compiler"
+	"bellamy/evaluator"
+	"bellamy/object"
+)
+
+// Session runs programs one after another, carrying over what they leave behind: the
+// variables they define at the top level and the modules they import. The REPL runs
+// every line in the same Session, while separate Sessions never share any state.
+type Session struct {
+	options   evaluator.Options
+	symbols   *compiler.SymbolTable
+	constants []object.Object
+	globals   *Globals
+	modules   *evaluator.ModuleCache
+}
+
+// NewSession creates a Session that runs programs with options
+func NewSession(options evaluator.Options) *Session {
+	return &Session{
+		options: options,
+		symbols: compiler.NewSymbolTable(),
+		globals: NewGlobals(),
+		modules: evaluator.NewModuleCache(),
+	}
+}
+
+// Define gives the programs of the session a global variable
+func (s *Session) Define(name string, value object.Object) {
+	symbol := s.symbols.Define(name)
+	s.globals.Set(symbol.Index, value)
+}
+
+// Run compiles program and runs it, returning its result or the error that stopped it
+func (s *Session) Run(program *ast.Program) object.Object {
+	c := compiler.NewWithState(s.symbols, s.constants)
+	if err := c.Compile(program); err != nil {
+		return object.NewError("%s", err)
+	}
+	bytecode := c.Bytecode()
+	s.constants = bytecode.Constants
+	return s.newVM(bytecode).Run()
+}
+
+func (s *Session) newVM(bytecode *compiler.Bytecode) *VM {
+	s.globals.scope = bytecode.Globals
+	s.globals.grow(len(bytecode.Globals.Names))
+	prog := &program{constants: bytecode.Constants, globals: s.globals}
+	vm := &VM{
+		main:    &Closure{Fn: bytecode.Main, program: prog},
+		stack:   make([]object.Object, StackSize),
+		frames:  make([]Frame, 64),
+		session: s,
+	}
+	vm.operators = evaluator.NewOperators(s.options, vm.apply)
+	return vm
+}
@@ -0,0 +1,419 @@
+package vm
+
+import (
+	"bellamy/builtins/static"
+	"bellamy/compiler"
+	"bellamy/evaluator"
+	"bellamy/object"
+	"bytes"
+)
+
+const (
+	StackSize = 2048
+	// MaxFrames limits how deep calls can nest before the program is stopped
+	MaxFrames = 1 << 20
+)
+
+var builtins = static.StaticBuiltins
+
+// Frame is a call to a closure in progress
+type Frame struct {
+	cl  *Closure
+	ip  int
+	env *env
+	// base is the height the stack is put back to when the call returns
+	base int
+}
+
+// VM runs compiled bytecode. It shares the object types, builtins and operators of
+// the evaluator, so programs behave the same whichever of the two runs them.
+type VM struct {
+	main    *Closure
+	stack   []object.Object
+	sp      int // the next free slot on the stack
+	frames  []Frame
+	fp      int // the number of frames in use
+	session *Session
+	// operators applies the operators the vm has no fast path for
+	operators *evaluator.Operators
+}
+
+// New creates a VM for bytecode compiled on its own, in a Session of its own with the
+// default options. Programs that build on each other are run by a Session instead.
+func New(bytecode *compiler.Bytecode) *VM {
+	return NewSession(evaluator.Options{}).newVM(bytecode)
+}
+
+// Run runs the program, returning its result or the error that stopped it. Like the
+// evaluator, it turns any panic inside the vm into an error.
+func (vm *VM) Run() (result object.Object) {
+	defer func() {
+		if r := recover(); r != nil {
+			result = object.NewError("internal error: %v", r)
+		}
+	}()
+
+	if err := vm.pushFrame(vm.main, nil, 0); err != nil {
+		return err
+	}
+	return vm.run(0)
+}
+
+// run executes instructions until the frames are back down to stop, returning the
+// value the last of them returns
+func (vm *VM) run(stop int) object.Object {
+	for {
+		f := &vm.frames[vm.fp-1]
+		ins := f.cl.Fn.Instructions
+		start := f.ip
+		op := compiler.Opcode(ins[start])
+		f.ip++
+
+		var result object.Object
+		switch op {
+		case compiler.OpConstant:
+			vm.push(f.cl.program.constants[vm.read32(f)])
+		case compiler.OpPop:
+			vm.sp--
+		case compiler.OpDupTwo:
+			vm.push(vm.stack[vm.sp-2])
+			vm.push(vm.stack[vm.sp-2])
+		case compiler.OpNull:
+			vm.push(object.NULL)
+		case compiler.OpTrue:
+			vm.push(object.TRUE)
+		case compiler.OpFalse:
+			vm.push(object.FALSE)
+
+		case compiler.OpAdd, compiler.OpSub, compiler.OpMul, compiler.OpDiv, compiler.OpMod,
+			compiler.OpEqual, compiler.OpNotEqual, compiler.OpLess, compiler.OpLessEqual,
+			compiler.OpGreater, compiler.OpGreaterEqual:
+			left, right := vm.stack[vm.sp-2], vm.stack[vm.sp-1]
+			if l, ok := left.(*object.Integer); ok {
+				if r, ok := right.(*object.Integer); ok {
+					result = integerOperation(op, l.Value, r.Value, vm.session.options.CheckedArithmetic)
+				}
+			}
+			if result == nil {
+				result = vm.operators.Infix(compiler.InfixOperator(op), left, right)
+			}
+			vm.sp -= 2
+			vm.push(result)
+		case compiler.OpMinus:
+			result = vm.operators.Prefix("-", vm.pop())
+			vm.push(result)
+		case compiler.OpBang:
+			result = vm.operators.Prefix("!", vm.pop())
+			vm.push(result)
+		case compiler.OpTruthy:
+			vm.push(booleanObject(object.IsTruthy(vm.pop())))
+
+		case compiler.OpJump:
+			f.ip = vm.read32(f)
+		case compiler.OpJumpNotTruthy:
+			target := vm.read32(f)
+			if !object.IsTruthy(vm.pop()) {
+				f.ip = target
+			}
+		case compiler.OpJumpTruthy:
+			target := vm.read32(f)
+			if object.IsTruthy(vm.pop()) {
+				f.ip = target
+			}
+		case compiler.OpJumpNull:
+			target := vm.read32(f)
+			if vm.stack[vm.sp-1] == object.NULL {
+				f.ip = target
+			}
+		case compiler.OpJumpNotNull:
+			target := vm.read32(f)
+			if vm.stack[vm.sp-1] != object.NULL {
+				f.ip = target
+			} else {
+				vm.sp--
+			}
+
+		case compiler.OpGetGlobal:
+			index := vm.read32(f)
+			g := f.cl.program.globals
+			if value := g.values[index]; value != nil {
+				vm.push(value)
+			} else {
+				result = vm.getName(f, g.scope.Names[index])
+			}
+		case compiler.OpSetGlobal:
+			f.cl.program.globals.values[vm.read32(f)] = vm.stack[vm.sp-1]
+		case compiler.OpAssignGlobal:
+			index := vm.read32(f)
+			g := f.cl.program.globals
+			if g.values[index] != nil {
+				g.values[index] = vm.stack[vm.sp-1]
+			} else {
+				result = vm.assignName(f, g.scope.Names[index])
+			}
+		case compiler.OpGetLocal:
+			index := vm.read32(f)
+			if value := f.env.slots[index]; value != nil {
+				vm.push(value)
+			} else {
+				result = vm.getName(f, f.env.scope.Names[index])
+			}
+		case compiler.OpSetLocal:
+			f.env.slots[vm.read32(f)] = vm.stack[vm.sp-1]
+		case compiler.OpAssignLocal:
+			index := vm.read32(f)
+			if f.env.slots[index] != nil {
+				f.env.slots[index] = vm.stack[vm.sp-1]
+			} else {
+				result = vm.assignName(f, f.env.scope.Names[index])
+			}
+		case compiler.OpGetFree:
+			e := vm.outer(f)
+			index := vm.read32(f)
+			if value := e.slots[index]; value != nil {
+				vm.push(value)
+			} else {
+				result = vm.getName(f, e.scope.Names[index])
+			}
+		case compiler.OpAssignFree:
+			e := vm.outer(f)
+			index := vm.read32(f)
+			if e.slots[index] != nil {
+				e.slots[index] = vm.stack[vm.sp-1]
+			} else {
+				result = vm.assignName(f, e.scope.Names[index])
+			}
+		case compiler.OpGetName:
+			result = vm.getName(f, vm.name(f))
+		case compiler.OpAssignName:
+			result = vm.assignName(f, vm.name(f))
+		case compiler.OpJumpIfSet:
+			index := vm.read32(f)
+			target := vm.read32(f)
+			if f.env.slots[index] != nil {
+				f.ip = target
+			}
+
+		case compiler.OpArray:
+			n := vm.read32(f)
+			elements := make([]object.Object, n)
+			copy(elements, vm.stack[vm.sp-n:vm.sp])
+			vm.sp -= n
+			vm.push(&object.Array{Elements: elements})
+		case compiler.OpHash:
+			n := vm.read32(f)
+			hash := object.NewHash()
+			for i := vm.sp - 2*n; i < vm.sp; i += 2 {
+				hash.Set(vm.stack[i].(object.Hashable), vm.stack[i+1])
+			}
+			vm.sp -= 2 * n
+			vm.push(hash)
+		case compiler.OpHashKey:
+			key, err := evaluator.HashKey(vm.pop())
+			if err != nil {
+				return vm.fail(err, start, stop)
+			}
+			vm.push(key)
+		case compiler.OpTemplate:
+			n := vm.read32(f)
+			var out bytes.Buffer
+			for _, part := range vm.stack[vm.sp-n : vm.sp] {
+				out.WriteString(part.Inspect())
+			}
+			vm.sp -= n
+			vm.push(&object.String{Value: out.String()})
+		case compiler.OpIndex:
+			index := vm.pop()
+			result = evaluator.Index(vm.pop(), index)
+			vm.push(result)
+		case compiler.OpSlice:
+			bounds := int(ins[f.ip])
+			f.ip++
+			var start, end object.Object
+			if bounds&compiler.SliceEnd != 0 {
+				end = vm.pop()
+			}
+			if bounds&compiler.SliceStart != 0 {
+				start = vm.pop()
+			}
+			result = evaluator.Slice(vm.pop(), start, end)
+			vm.push(result)
+		case compiler.OpMember:
+			result = evaluator.Member(vm.pop(), vm.name(f))
+			vm.push(result)
+		case compiler.OpMemberTarget:
+			if obj := vm.stack[vm.sp-1]; obj.Type() != object.HASH_OBJ {
+				result = object.NewError("cannot assign to member of %s", obj.Type())
+			}
+		case compiler.OpSetIndex:
+			val := vm.pop()
+			index := vm.pop()
+			result = evaluator.SetIndex(vm.pop(), index, val)
+			vm.push(result)
+
+		case compiler.OpCall, compiler.OpTailCall:
+			argc := int(ins[f.ip])
+			f.ip++
+			result = vm.call(argc, nil, op == compiler.OpTailCall)
+		case compiler.OpCallNamed, compiler.OpTailCallNamed:
+			argc := int(ins[f.ip])
+			f.ip++
+			names := f.cl.program.constants[vm.read32(f)].(*object.Tuple)
+			result = vm.call(argc, names, op == compiler.OpTailCallNamed)
+		case compiler.OpReturnValue:
+			value := vm.pop()
+			vm.sp = f.base
+			vm.fp--
+			if vm.fp == stop {
+				return value
+			}
+			vm.push(value)
+		case compiler.OpClosure:
+			fn := f.cl.program.constants[vm.read32(f)].(*compiler.Function)
+			vm.push(&Closure{Fn: fn, env: f.env, program: f.cl.program})
+
+		case compiler.OpPushScope:
+			f.env = newEnv(f.cl.Fn.Blocks[vm.read32(f)], f.env)
+		case compiler.OpPopScope:
+			f.env = f.env.outer
+		case compiler.OpIter:
+			items, err := evaluator.Iterate(vm.pop())
+			if err != nil {
+				return vm.fail(err, start, stop)
+			}
+			vm.push(&iterator{items: items})
+		case compiler.OpIterNext:
+			target := vm.read32(f)
+			it := vm.stack[vm.sp-1].(*iterator)
+			if it.next < len(it.items) {
+				vm.push(it.items[it.next])
+				it.next++
+			} else {
+				f.ip = target
+			}
+
+		case compiler.OpImport:
+			result = vm.session.modules.Import(vm.pop(), f.cl.Fn.PosAt(start), vm.session.runModule)
+			vm.push(result)
+
+		default:
+			result = object.NewError("unknown opcode %d", op)
+		}
+
+		if result != nil && result.Type() == object.ERROR_OBJ {
+			return vm.fail(result, start, stop)
+		}
+	}
+}
+
+// fail stops the frames run by run(stop) because of err, which is raised by the
+// instruction at offset in the current frame unless it already has a position
+func (vm *VM) fail(err object.Object, offset, stop int) object.Object {
+	errObj := err.(*object.Error)
+	if !errObj.Pos.IsValid() {
+		errObj.Pos = vm.frames[vm.fp-1].cl.Fn.PosAt(offset)
+	}
+	vm.sp = vm.frames[stop].base
+	vm.fp = stop
+	return errObj
+}
+
+func (vm *VM) pushFrame(cl *Closure, e *env, base int) *object.Error {
+	if vm.fp == MaxFrames {
+		return object.NewError("stack overflow: calls nested more than %d deep", MaxFrames)
+	}
+	if vm.fp == len(vm.frames) {
+		vm.frames = append(vm.frames, make([]Frame, len(vm.frames))...)
+	}
+	vm.frames[vm.fp] = Frame{cl: cl, env: e, base: base}
+	vm.fp++
+	return nil
+}
+
+func (vm *VM) push(o object.Object) {
+	if vm.sp == len(vm.stack) {
+		vm.stack = append(vm.stack, make([]object.Object, len(vm.stack))...)
+	}
+	vm.stack[vm.sp] = o
+	vm.sp++
+}
+
+func (vm *VM) pop() object.Object {
+	vm.sp--
+	return vm.stack[vm.sp]
+}
+
+// read32 reads a four byte operand of the current instruction
+func (vm *VM) read32(f *Frame) int {
+	operand := int(compiler.ReadUint32(f.cl.Fn.Instructions[f.ip:]))
+	f.ip += 4
+	return operand
+}
+
+// name reads an operand giving the constant that holds a name
+func (vm *VM) name(f *Frame) string {
+	return f.cl.program.constants[vm.read32(f)].(*object.String).Value
+}
+
+// outer reads the operand of OpGetFree and OpAssignFree giving how many scopes out to go
+func (vm *VM) outer(f *Frame) *env {
+	e := f.env
+	for depth := vm.read32(f); depth > 0; depth-- {
+		e = e.outer
+	}
+	return e
+}
+
+func (vm *VM) getName(f *Frame, name string) object.Object {
+	value, ok := lookup(name, f.env, f.cl.program.globals)
+	if !ok {
+		return object.NewError("identifier not found: %s", name)
+	}
+	vm.push(value)
+	return nil
+}
+
+func (vm *VM) assignName(f *Frame, name string) object.Object {
+	if !assign(name, vm.stack[vm.sp-1], f.env, f.cl.program.globals) {
+		return object.NewError("assignment to undeclared variable: %s", name)
+	}
+	return nil
+}
+
+// integerOperation applies an infix operator to two integers, leaving the cases that
+// can fail to the evaluator by returning nil. checked is Options.CheckedArithmetic.
+func integerOperation(op compiler.Opcode, l, r int64, checked bool) object.Object {
+	switch op {
+	case compiler.OpEqual:
+		return booleanObject(l == r)
+	case compiler.OpNotEqual:
+		return booleanObject(l != r)
+	case compiler.OpLess:
+		return booleanObject(l < r)
+	case compiler.OpLessEqual:
+		return booleanObject(l <= r)
+	case compiler.OpGreater:
+		return booleanObject(l > r)
+	case compiler.OpGreaterEqual:
+		return booleanObject(l >= r)
+	}
+	if checked {
+		return nil
+	}
+	switch op {
+	case compiler.OpAdd:
+		return &object.Integer{Value: l + r}
+	case compiler.OpSub:
+		return &object.Integer{Value: l - r}
+	case compiler.OpMul:
+		return &object.Integer{Value: l * r}
+	}
+	return nil
+}
+
+func booleanObject(b bool) *object.Boolean {
+	if b {
+		return object.TRUE
+	}
+	return object.FALSE
+}
@@ -0,0 +1,141 @@
+package vm
+
+import (
+	"bellamy/compiler"
+	"bellamy/evaluator"
+	"bellamy/lexer"
+	"bellamy/object"
+	"bellamy/parser"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const fib = "let fib = fn(n) { if (n < 2) { n } else { fib(n - 1) + fib(n - 2) } };"
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{fib + "fib(15)", "610"},
+		{"map([1, 2, 3], fn(x) { x * 10 })", "[10, 20, 30]"},
+		{"reduce(map([1, 2], fn(x) { [x] }), fn(acc, x) { acc + x[0] }, 0)", "3"},
+		{"let count = 0; map([1, 2, 3], fn(x) { count = count + x }); count", "6"},
+		{"let f = fn() { g() }; let g = fn() { 5 }; f()", "5"},
+		{"map([1], fn(x) { x + true })", "ERROR: 1:20: type mismatch: INTEGER + BOOLEAN"},
+		{"let f = fn(n) { if (n == 0) { 0 } else { 1 + f(n - 1) } }; f(2000000)", "ERROR: 1:47: stack overflow: calls nested more than 1048576 deep"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, run(t, tt.input).Inspect(), tt.input)
+	}
+}
+
+func TestSession(t *testing.T) {
+	session := NewSession(evaluator.Options{})
+	session.Define("a", &object.Integer{Value: 1})
+
+	lines := []struct {
+		input    string
+		expected string
+	}{
+		{"a", "1"},
+		{"let add = fn(x) { a + x };", "fn(x) {\n(a + x)\n}"},
+		{"a = 10; add(5)", "15"},
+		{"b", "ERROR: 1:1: identifier not found: b"},
+		{"let b = add(a); b", "20"},
+	}
+
+	for _, tt := range lines {
+		program := parser.New(lexer.New(tt.input)).ParseProgram()
+		assert.Equal(t, tt.expected, session.Run(program).Inspect(), tt.input)
+	}
+}
+
+func TestRecoverPanics(t *testing.T) {
+	session := NewSession(evaluator.Options{})
+	session.Define("boom", &object.Builtin{Fn: func(object.Applier, ...object.Object) object.Object {
+		panic("boom")
+	}})
+
+	program := parser.New(lexer.New("map([1], fn(x) { boom() })")).ParseProgram()
+	assert.Equal(t, "ERROR: internal error: boom", session.Run(program).Inspect())
+}
+
+func TestSessionsDoNotShareModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bellamy")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	source := `let count = 0; let bump = fn() { count = count + 1 };`
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "counter.bel"), []byte(source), 0644))
+
+	input := `let m = import("counter.bel"); m.bump()`
+	program := parser.New(lexer.NewWithFile(input, filepath.Join(dir, "main.bel"))).ParseProgram()
+
+	session := NewSession(evaluator.Options{})
+	assert.Equal(t, "1", session.Run(program).Inspect())
+	assert.Equal(t, "2", session.Run(program).Inspect())
+	assert.Equal(t, "1", NewSession(evaluator.Options{}).Run(program).Inspect())
+}
+
+// TestLargePrograms runs programs with constants, jump targets and element counts
+// past 65535, beyond what two byte operands can hold
+func TestLargePrograms(t *testing.T) {
+	// repeat joins n copies of item, each with %d replaced by its position
+	repeat := func(item, sep string, n int) string {
+		items := make([]string, n)
+		for i := range items {
+			items[i] = strings.Replace(item, "%d", strconv.Itoa(i), -1)
+		}
+		return strings.Join(items, sep)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [" + repeat("%d", ", ", 70000) + "]; len(a) + a[69999]", "139999"},
+		{"let h = {" + repeat("%d: %d", ", ", 70000) + "}; len(h) + h[69999]", "139999"},
+		{"let x = 0; if (x == 0) { " + repeat("x += 1;", " ", 20000) + " } else { x = 0 - 1 }; x", "20000"},
+		{"let x = 0; while (x < 1) { " + repeat("x += 1;", " ", 20000) + " }; x", "20000"},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, run(t, tt.input).Inspect(), tt.input[:40])
+	}
+
+	// A session adds the constants of every line to the same pool
+	session := NewSession(evaluator.Options{})
+	var result object.Object
+	for i := 0; i < 7000; i++ {
+		line := "len([" + repeat(strconv.Itoa(i), ", ", 10) + "]) + " + strconv.Itoa(i)
+		result = session.Run(parser.New(lexer.New(line)).ParseProgram())
+	}
+	assert.Equal(t, "7009", result.Inspect())
+}
+
+func run(t testing.TB, input string) object.Object {
+	program := parser.New(lexer.New(input)).ParseProgram()
+	c := compiler.New()
+	assert.NoError(t, c.Compile(program))
+	return New(c.Bytecode()).Run()
+}
+
+func BenchmarkFibVM(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		run(b, fib+"fib(30)")
+	}
+}
+
+func BenchmarkFibEvaluator(b *testing.B) {
+	program := parser.New(lexer.New(fib + "fib(30)")).ParseProgram()
+	for i := 0; i < b.N; i++ {
+		evaluator.Eval(program, object.NewEnvironment())
+	}
+}